@@ -0,0 +1,162 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/4nd3r5on/jsontype"
+)
+
+// archiveSeparator separates the archive label from the member path,
+// e.g. "samples.zip!/users/get.json"
+const archiveSeparator = "!/"
+
+// memberFilter selects archive members by path patterns
+type memberFilter struct {
+	// members must match one of the patterns, empty means any member
+	include []string
+	// members matching one of the patterns are skipped
+	exclude []string
+}
+
+// matchMember matches the member path against a glob pattern.
+// Patterns without a slash match the base name, "**" matches any number of directories
+func matchMember(pattern, name string) bool {
+	if !strings.Contains(pattern, "/") {
+		matched, _ := path.Match(pattern, path.Base(name))
+		return matched
+	}
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func (f *memberFilter) accepts(name string) bool {
+	for _, pattern := range f.exclude {
+		if matchMember(pattern, name) {
+			return false
+		}
+	}
+	if len(f.include) == 0 {
+		return true
+	}
+	for _, pattern := range f.include {
+		if matchMember(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectArchive returns "zip" or "tar" if the input is an archive, empty string otherwise
+func detectArchive(br *bufio.Reader) string {
+	header, _ := br.Peek(262)
+	switch {
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")), bytes.HasPrefix(header, []byte("PK\x05\x06")):
+		return "zip"
+	case len(header) >= 262 && string(header[257:262]) == "ustar":
+		return "tar"
+	}
+	return ""
+}
+
+// acceptsMember reports if the archive member should be read.
+// Members are filtered by extension like files in directories and by member patterns
+func (c *parseConfig) acceptsMember(name string) bool {
+	return c.inputs.accepts(name, true) && c.members.accepts(name)
+}
+
+// readMember reads a single archive member under "<archive>!/<member>"
+func (c *parseConfig) readMember(r io.ReadCloser, label, name string, parse parseFunc) error {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	if !c.acceptsMember(name) {
+		slog.Debug("skipping archive member", "archive", label, "member", name)
+		return r.Close()
+	}
+	return c.readInput(r, label+archiveSeparator+name, parse)
+}
+
+// readTar reads every accepted regular file of the tar archive
+func (c *parseConfig) readTar(r io.Reader, label string, parse parseFunc) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read %s: %w", label, err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := c.readMember(io.NopCloser(tr), label, header.Name, parse); err != nil {
+			return err
+		}
+	}
+}
+
+// readZip reads every accepted file of the zip archive.
+// Zip needs random access, so archives that aren't plain files
+// (compressed, stdin or nested ones) are copied to a temporary file first
+func (c *parseConfig) readZip(r io.Reader, file *os.File, label string, parse parseFunc) error {
+	if file == nil {
+		spilled, err := c.spillZip(r, label)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(spilled.Name())
+		defer spilled.Close()
+		file = spilled
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	zr, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		return fmt.Errorf("read %s: %w", label, err)
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("read %s%s%s: %w", label, archiveSeparator, f.Name, err)
+		}
+		if err := c.readMember(rc, label, f.Name, parse); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// spillZip copies the archive to a temporary file, failing with a LimitError
+// if it's larger than -max-bytes
+func (c *parseConfig) spillZip(r io.Reader, label string) (*os.File, error) {
+	tmp, err := os.CreateTemp("", "jsontype-*.zip")
+	if err != nil {
+		return nil, err
+	}
+	limit := c.opts.Limits.MaxBytes
+	if limit > 0 {
+		r = io.LimitReader(r, limit+1)
+	}
+	n, err := io.Copy(tmp, r)
+	if err == nil && limit > 0 && n > limit {
+		err = &jsontype.LimitError{Limit: jsontype.LimitBytes, Max: limit}
+	}
+	if err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("read %s: %w", label, err)
+	}
+	return tmp, nil
+}
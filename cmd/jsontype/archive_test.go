@@ -0,0 +1,187 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/4nd3r5on/jsontype"
+)
+
+type archiveMember struct {
+	name string
+	data []byte
+}
+
+func zipArchive(t *testing.T, members ...archiveMember) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, m := range members {
+		w, err := zw.Create(m.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write(m.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func tarGzArchive(t *testing.T, members ...archiveMember) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, m := range members {
+		header := &tar.Header{Name: m.name, Mode: 0o644, Size: int64(len(m.data)), Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(m.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// readLabels reads the input and returns labels of the parsed inputs
+func readLabels(t *testing.T, c *parseConfig, r io.Reader, label string) ([]string, error) {
+	t.Helper()
+	var labels []string
+	err := c.readInput(io.NopCloser(r), label, func(r io.Reader, label string) error {
+		if _, err := io.Copy(io.Discard, r); err != nil {
+			return err
+		}
+		labels = append(labels, label)
+		return nil
+	})
+	return labels, err
+}
+
+func testConfig() *parseConfig {
+	logger := slog.New(slog.DiscardHandler)
+	return &parseConfig{
+		opts:     jsontype.ParseOptions{Logger: logger},
+		logger:   logger,
+		failures: &jsontype.InputErrors{},
+	}
+}
+
+func TestReadArchives(t *testing.T) {
+	record := []byte(`{"id": 1}`)
+	inner := zipArchive(t,
+		archiveMember{"inner/a.json", record},
+		archiveMember{"inner/notes.txt", []byte("text")},
+	)
+	archive := zipArchive(t,
+		archiveMember{"api/users/get.json", record},
+		archiveMember{"api/debug_dump.json", record},
+		archiveMember{"readme.md", []byte("# docs")},
+		archiveMember{"nested.zip", inner},
+		archiveMember{"nested.tar.gz", tarGzArchive(t, archiveMember{"./b.json", record})},
+	)
+
+	tests := []struct {
+		name    string
+		members memberFilter
+		want    []string
+	}{
+		{
+			name: "every member",
+			want: []string{
+				"samples.zip!/api/users/get.json",
+				"samples.zip!/api/debug_dump.json",
+				"samples.zip!/nested.zip!/inner/a.json",
+				"samples.zip!/nested.tar.gz!/b.json",
+			},
+		},
+		{
+			name:    "include patterns",
+			members: memberFilter{include: []string{"api/**/*.json", "nested.zip"}},
+			want: []string{
+				"samples.zip!/api/users/get.json",
+				"samples.zip!/api/debug_dump.json",
+			},
+		},
+		{
+			name:    "base name patterns",
+			members: memberFilter{exclude: []string{"debug_*", "*.tar.gz"}},
+			want: []string{
+				"samples.zip!/api/users/get.json",
+				"samples.zip!/nested.zip!/inner/a.json",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := testConfig()
+			c.members = tt.members
+			got, err := readLabels(t, c, bytes.NewReader(archive), "samples.zip")
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("zip file read in place", func(t *testing.T) {
+		p := filepath.Join(t.TempDir(), "samples.zip")
+		if err := os.WriteFile(p, archive, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		var labels []string
+		err := testConfig().readFile(p, func(r io.Reader, label string) error {
+			labels = append(labels, filepath.ToSlash(label))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if len(labels) != 4 || labels[0] != filepath.ToSlash(p)+"!/api/users/get.json" {
+			t.Errorf("unexpected labels %v", labels)
+		}
+	})
+}
+
+func TestReadZip_MaxBytes(t *testing.T) {
+	archive := zipArchive(t, archiveMember{"a.json", bytes.Repeat([]byte(" "), 1000)})
+	var compressed bytes.Buffer
+	gw := gzip.NewWriter(&compressed)
+	gw.Write(archive)
+	gw.Close()
+
+	c := testConfig()
+	c.opts.Limits.MaxBytes = int64(len(archive)) - 1
+	_, err := readLabels(t, c, bytes.NewReader(compressed.Bytes()), "samples.zip.gz")
+	var limitErr *jsontype.LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != jsontype.LimitBytes {
+		t.Fatalf("expected a bytes LimitError, got %v", err)
+	}
+
+	c.opts.Limits.MaxBytes = int64(len(archive))
+	labels, err := readLabels(t, c, bytes.NewReader(compressed.Bytes()), "samples.zip.gz")
+	if err != nil || !slices.Equal(labels, []string{"samples.zip.gz!/a.json"}) {
+		t.Errorf("expected the archive within the limit to be read, got %v, %v", labels, err)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// compression formats detected by magic bytes
+var compressionMagic = []struct {
+	name  string
+	magic []byte
+}{
+	{"gzip", []byte{0x1f, 0x8b}},
+	{"bzip2", []byte("BZh")},
+	{"xz", []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}},
+	{"zstd", []byte{0x28, 0xb5, 0x2f, 0xfd}},
+}
+
+// compressionExtensions are stripped from file names before checking the format by extension
+var compressionExtensions = []string{".gz", ".gzip", ".bz2", ".xz", ".zst", ".zstd"}
+
+// trimCompressionExt removes a compression extension from the file name
+func trimCompressionExt(name string) string {
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, compressed := range compressionExtensions {
+		if ext == compressed {
+			return strings.TrimSuffix(name, filepath.Ext(name))
+		}
+	}
+	return name
+}
+
+// decompress detects compression of the input by magic bytes and returns a reader
+// of the decompressed data along with the format name, uncompressed input is returned as is
+// with an empty format name. Closing the reader doesn't close r
+func decompress(r io.Reader) (io.ReadCloser, string, error) {
+	br := bufio.NewReader(r)
+	// Peek returns an error for inputs shorter than the magic, what was read is still checked
+	header, _ := br.Peek(6)
+
+	format := ""
+	for _, c := range compressionMagic {
+		if bytes.HasPrefix(header, c.magic) {
+			format = c.name
+			break
+		}
+	}
+
+	switch format {
+	case "gzip":
+		zr, err := gzip.NewReader(br)
+		return zr, format, err
+	case "bzip2":
+		return io.NopCloser(bzip2.NewReader(br)), format, nil
+	case "xz":
+		xr, err := xz.NewReader(br)
+		return io.NopCloser(xr), format, err
+	case "zstd":
+		// parallel inputs are decoded by separate goroutines already
+		zr, err := zstd.NewReader(br, zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, format, err
+		}
+		return zr.IOReadCloser(), format, nil
+	}
+	return io.NopCloser(br), "", nil
+}
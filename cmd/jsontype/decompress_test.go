@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+func TestDecompress(t *testing.T) {
+	const record = `{"id": 1, "name": "bzip2"}` + "\n"
+
+	compress := func(newWriter func(w io.Writer) (io.WriteCloser, error)) []byte {
+		t.Helper()
+		var buf bytes.Buffer
+		w, err := newWriter(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := io.WriteString(w, record); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+	// the standard library has no bzip2 writer
+	bzipped, err := os.ReadFile("testdata/record.json.bz2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		input  []byte
+		format string
+		want   string
+	}{
+		{"gzip", compress(func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil }), "gzip", record},
+		{"bzip2", bzipped, "bzip2", record},
+		{"xz", compress(func(w io.Writer) (io.WriteCloser, error) { return xz.NewWriter(w) }), "xz", record},
+		{"zstd", compress(func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) }), "zstd", record},
+		{"plain", []byte(record), "", record},
+		// shorter than the longest magic
+		{"short", []byte("1"), "", "1"},
+		{"empty", nil, "", ""},
+		// starts like gzip magic but is cut short
+		{"magic prefix", []byte{0x1f}, "", "\x1f"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, format, err := decompress(bytes.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("decompress: %v", err)
+			}
+			defer r.Close()
+			if format != tt.format {
+				t.Errorf("format: got %q, want %q", format, tt.format)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrimCompressionExt(t *testing.T) {
+	tests := map[string]string{
+		"data.json.gz":    "data.json",
+		"data.ndjson.ZST": "data.ndjson",
+		"data.json.bz2":   "data.json",
+		"data.json":       "data.json",
+		"archive.tgz":     "archive.tgz",
+	}
+	for name, want := range tests {
+		if got := trimCompressionExt(name); got != want {
+			t.Errorf("%s: got %s, want %s", name, got, want)
+		}
+	}
+}
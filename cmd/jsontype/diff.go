@@ -0,0 +1,142 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/4nd3r5on/jsontype"
+)
+
+// Exit codes of the diff command, same as diff(1)
+const (
+	diffExitSame    = 0
+	diffExitChanged = 1
+	diffExitError   = 2
+)
+
+// runDiff compares shapes of two inputs (or two labels of the merged inputs)
+// and returns the exit code
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+
+	var pf parseFlags
+	var outPath string
+	var format string
+	var oldLabel string
+	var newLabel string
+
+	pf.register(fs)
+	fs.StringVar(&outPath, "out", "", "output file (default stdout)")
+	fs.StringVar(&format, "format", "text", "output format: text|json")
+	fs.StringVar(&oldLabel, "old-label", "", "diff by label: merge every input and compare this label against -new-label")
+	fs.StringVar(&newLabel, "new-label", "", "diff by label: the label compared against -old-label")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage:\n  %[1]s diff [flags] old new\n  %[1]s diff [flags] -old-label old -new-label new file|dir|glob ...\n\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(fs.Output(), "Exit status is 0 if the shapes are the same, 1 if they differ and 2 on errors.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return diffExitError
+	}
+
+	fail := func(err error) int {
+		fmt.Fprintf(os.Stderr, "jsontype diff: %v\n", err)
+		printSnippet(os.Stderr, err)
+		return diffExitError
+	}
+
+	cfg, err := pf.config()
+	if err != nil {
+		return fail(err)
+	}
+	if cfg.listDetectors {
+		cfg.printDetectors(os.Stdout)
+		return 0
+	}
+
+	switch format {
+	case "text", "json":
+	default:
+		return fail(fmt.Errorf("invalid output format: %s", format))
+	}
+
+	byLabel := oldLabel != "" || newLabel != ""
+	switch {
+	case byLabel && (oldLabel == "" || newLabel == ""):
+		return fail(fmt.Errorf("-old-label and -new-label must be set together"))
+	case byLabel && fs.NArg() == 0:
+		fs.Usage()
+		return diffExitError
+	case !byLabel && fs.NArg() != 2:
+		fs.Usage()
+		return diffExitError
+	}
+
+	load := func(paths ...string) (*jsontype.Merger, error) {
+		files, err := cfg.inputs.expand(paths)
+		if err != nil {
+			return nil, err
+		}
+		merger := jsontype.NewMerger([]string{})
+		if err := cfg.mergeFiles(merger, files); err != nil {
+			return nil, err
+		}
+		if cfg.opts.NumberAnalysis {
+			jsontype.ResolveEpochTypes(merger)
+		}
+		return merger, nil
+	}
+
+	var diff *jsontype.SchemaDiff
+	if byLabel {
+		merger, err := load(fs.Args()...)
+		if err != nil {
+			return fail(err)
+		}
+		for _, label := range []string{oldLabel, newLabel} {
+			if _, exists := merger.LabeledTypesMap[label]; !exists {
+				return fail(fmt.Errorf("label %q not found in the inputs", label))
+			}
+		}
+		diff = jsontype.DiffLabels(merger, oldLabel, newLabel)
+	} else {
+		oldMerger, err := load(fs.Arg(0))
+		if err != nil {
+			return fail(err)
+		}
+		newMerger, err := load(fs.Arg(1))
+		if err != nil {
+			return fail(err)
+		}
+		diff = jsontype.DiffMergers(oldMerger, newMerger)
+	}
+
+	out := os.Stdout
+	if outPath != "" {
+		f, err := os.Create(outPath)
+		if err != nil {
+			return fail(fmt.Errorf("open output: %w", err))
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if format == "json" {
+		err = diff.WriteJSON(out)
+	} else {
+		err = diff.WriteText(out)
+	}
+	if err != nil {
+		return fail(fmt.Errorf("write diff: %w", err))
+	}
+
+	if cfg.reportFailures(os.Stderr) {
+		return diffExitError
+	}
+	if diff.HasChanges() {
+		return diffExitChanged
+	}
+	return diffExitSame
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/4nd3r5on/jsontype"
+)
+
+// inputFormat tells how inputs are decoded
+type inputFormat string
+
+const (
+	formatJSON    inputFormat = "json"
+	formatNDJSON  inputFormat = "ndjson"
+	formatYAML    inputFormat = "yaml"
+	formatTOML    inputFormat = "toml"
+	formatJSON5   inputFormat = "json5"
+	formatMsgPack inputFormat = "msgpack"
+	formatCBOR    inputFormat = "cbor"
+	formatBSON    inputFormat = "bson"
+)
+
+// formatExtensions are the file extensions of every format
+var formatExtensions = map[inputFormat][]string{
+	formatJSON:    {"json"},
+	formatNDJSON:  {"ndjson", "jsonl", "ldjson"},
+	formatYAML:    {"yaml", "yml"},
+	formatTOML:    {"toml"},
+	formatJSON5:   {"json5", "jsonc"},
+	formatMsgPack: {"msgpack", "mpk"},
+	formatCBOR:    {"cbor"},
+	formatBSON:    {"bson"},
+}
+
+// archiveExtensions are read from directories along with the files of the input format
+var archiveExtensions = []string{"zip", "tar", "tgz"}
+
+func parseInputFormat(s string) (inputFormat, error) {
+	if s == "" || s == "auto" {
+		return "", nil
+	}
+	if _, exists := formatExtensions[inputFormat(s)]; !exists {
+		return "", fmt.Errorf("invalid input format: %s", s)
+	}
+	return inputFormat(s), nil
+}
+
+// detectFormat returns the format of the file by its extension, JSON by default
+func detectFormat(path string) inputFormat {
+	ext := strings.TrimPrefix(strings.ToLower(filepath.Ext(trimCompressionExt(path))), ".")
+	for format, exts := range formatExtensions {
+		if slices.Contains(exts, ext) {
+			return format
+		}
+	}
+	return formatJSON
+}
+
+// stream returns the stream decoding the input and the options to parse it with.
+// Documents of multi-document YAML inputs and BSON dumps are parsed as records like NDJSON
+func (c *parseConfig) stream(r io.Reader, label string) (jsontype.Stream, jsontype.ParseOptions, error) {
+	opts := c.opts
+	format := c.format
+	if format == "" {
+		format = detectFormat(label)
+	}
+
+	// concatenated documents are read at the root instead of as records
+	records := !opts.Concatenated
+	switch format {
+	case formatNDJSON:
+		opts.NDJSON = records
+	case formatYAML:
+		s := jsontype.NewYAMLStream(r)
+		multi, err := s.MultiDocument()
+		if err != nil {
+			return nil, opts, err
+		}
+		opts.NDJSON = opts.NDJSON || multi && records
+		return s, opts, nil
+	case formatTOML:
+		return jsontype.NewTOMLStream(r), opts, nil
+	case formatJSON5:
+		return jsontype.NewJSON5Stream(r), opts, nil
+	case formatMsgPack:
+		return jsontype.NewMsgPackStream(r), opts, nil
+	case formatCBOR:
+		return jsontype.NewCBORStream(r), opts, nil
+	case formatBSON:
+		opts.NDJSON = records
+		return jsontype.NewBSONStream(r), opts, nil
+	}
+	return jsontype.NewJSONStream(r), opts, nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/4nd3r5on/jsontype"
+)
+
+// isHARFiles reports if every file is a HAR capture
+func isHARFiles(files []string) bool {
+	for _, path := range files {
+		if !hasExtension(path, []string{"har"}) {
+			return false
+		}
+	}
+	return len(files) > 0
+}
+
+// mergeHAR returns a parseFunc merging bodies of HAR captures into the set
+func (c *parseConfig) mergeHAR(set *jsontype.EndpointSet) parseFunc {
+	return func(r io.Reader, label string) error {
+		if err := jsontype.MergeHARWithOptions(set, label, r, c.opts); err != nil {
+			return c.tolerate(label, fmt.Errorf("parse %s: %w", label, err))
+		}
+		return nil
+	}
+}
+
+// readHAR reads HAR captures from stdin and the files into an endpoint set
+func (c *parseConfig) readHAR(files []string, hasStdin bool) (*jsontype.EndpointSet, error) {
+	set := jsontype.NewEndpointSet()
+	if hasStdin {
+		if err := c.tolerate("stdin", c.readInput(io.NopCloser(os.Stdin), "stdin", c.mergeHAR(set))); err != nil {
+			return nil, err
+		}
+	}
+	parser := func(set *jsontype.EndpointSet, _ string) parseFunc {
+		return c.mergeHAR(set)
+	}
+	if err := readFiles(c, set, files, jsontype.NewEndpointSet, parser); err != nil {
+		return nil, err
+	}
+	if c.opts.NumberAnalysis {
+		for _, e := range set.Endpoints() {
+			jsontype.ResolveEpochTypes(e.Merger)
+		}
+	}
+	return set, nil
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// defaultExtensions are read from directories and archives when no extensions are included explicitly
+var defaultExtensions = []string{"json", "ndjson", "jsonl", "ldjson", "zip", "tar", "tgz"}
+
+// harExtensions are read from directories and archives in HAR mode
+var harExtensions = []string{"har", "zip", "tar", "tgz"}
+
+// inputFilter selects files found in directories and by glob patterns.
+// Files given explicitly are always read
+type inputFilter struct {
+	// descend into subdirectories of directory arguments
+	recursive bool
+	// extensions to read, empty means defaults for directories and any file for globs
+	include []string
+	// extensions read from directories and archives when include is empty, nil means defaultExtensions
+	defaults []string
+	// extensions to skip
+	exclude []string
+}
+
+// hasExtension reports if the file name ends with one of the extensions,
+// extensions may contain dots (e.g. "json.gz"). Compressed files also match
+// the extension of the file inside (data.json.gz matches "json")
+func hasExtension(name string, exts []string) bool {
+	name = strings.ToLower(filepath.Base(name))
+	uncompressed := trimCompressionExt(name)
+	for _, ext := range exts {
+		ext = "." + strings.ToLower(strings.TrimPrefix(ext, "."))
+		if strings.HasSuffix(name, ext) || strings.HasSuffix(uncompressed, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *inputFilter) accepts(name string, fromDir bool) bool {
+	if hasExtension(name, f.exclude) {
+		return false
+	}
+	switch {
+	case len(f.include) > 0:
+		return hasExtension(name, f.include)
+	case fromDir && f.defaults != nil:
+		return hasExtension(name, f.defaults)
+	case fromDir:
+		return hasExtension(name, defaultExtensions)
+	}
+	return true
+}
+
+func isHidden(name string) bool {
+	return strings.HasPrefix(name, ".") && name != "." && name != ".."
+}
+
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// expand resolves arguments into the list of files to read.
+// Directories and glob patterns are expanded into sorted lists of matching files,
+// a file met more than once (even spelled differently, like ./a.json and a.json)
+// is read only the first time
+func (f *inputFilter) expand(args []string) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	add := func(paths ...string) {
+		for _, p := range paths {
+			key := filepath.Clean(p)
+			if !seen[key] {
+				seen[key] = true
+				files = append(files, p)
+			}
+		}
+	}
+
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		switch {
+		case err == nil && info.IsDir():
+			found, err := f.walkDir(arg)
+			if err != nil {
+				return nil, err
+			}
+			if len(found) == 0 {
+				return nil, fmt.Errorf("no input files found in %s", arg)
+			}
+			add(found...)
+		case err == nil:
+			add(arg)
+		case isGlob(arg):
+			found, err := f.glob(arg)
+			if err != nil {
+				return nil, err
+			}
+			if len(found) == 0 {
+				return nil, fmt.Errorf("no input files match %s", arg)
+			}
+			add(found...)
+		default:
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// walkDir lists accepted files in the directory (and its subdirectories if recursive),
+// hidden files and directories are skipped
+func (f *inputFilter) walkDir(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root {
+			return nil
+		}
+		if isHidden(d.Name()) || d.IsDir() && !f.recursive {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() && f.accepts(p, true) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	// WalkDir visits entries in lexical order
+	return files, err
+}
+
+// glob lists accepted files matching the pattern.
+// Besides filepath.Match syntax "**" matches any number of directories
+func (f *inputFilter) glob(pattern string) ([]string, error) {
+	segments := strings.Split(filepath.ToSlash(pattern), "/")
+	for _, segment := range segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", pattern, err)
+		}
+	}
+
+	if !slices.Contains(segments, "**") {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		cleaned := strings.Split(filepath.ToSlash(filepath.Clean(pattern)), "/")
+		var files []string
+		for _, match := range matches {
+			if matchesHidden(cleaned, match) {
+				continue
+			}
+			if info, err := os.Stat(match); err == nil && !info.IsDir() && f.accepts(match, false) {
+				files = append(files, match)
+			}
+		}
+		return files, nil
+	}
+
+	// walk the longest directory prefix without wildcards
+	i := slices.IndexFunc(segments, isGlob)
+	base := strings.Join(segments[:i], "/")
+	switch {
+	case i == 0:
+		base = "."
+	case base == "":
+		base = "/"
+	}
+	base = filepath.FromSlash(base)
+
+	var files []string
+	err := filepath.WalkDir(base, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if p == base && os.IsNotExist(err) {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		if p != base && isHidden(d.Name()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(base, p)
+		if err != nil {
+			return err
+		}
+		if matchSegments(segments[i:], strings.Split(filepath.ToSlash(rel), "/")) && f.accepts(p, false) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// matchesHidden reports if a wildcard of the pattern matched a hidden file or directory,
+// like in shells they have to be named with a leading dot
+func matchesHidden(pattern []string, match string) bool {
+	for i, name := range strings.Split(filepath.ToSlash(match), "/") {
+		if i < len(pattern) && isHidden(name) && !strings.HasPrefix(pattern[i], ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSegments matches path segments against pattern segments, "**" matches zero or more segments
+func matchSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+	if pattern[0] == "**" {
+		for skip := range len(name) + 1 {
+			if matchSegments(pattern[1:], name[skip:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(name) == 0 {
+		return false
+	}
+	matched, _ := path.Match(pattern[0], name[0])
+	return matched && matchSegments(pattern[1:], name[1:])
+}
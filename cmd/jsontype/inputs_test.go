@@ -0,0 +1,131 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeTree creates empty files (and their directories) under root
+func writeTree(t *testing.T, root string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestInputFilterExpand(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root,
+		"b.json", "a.json", "notes.txt", "data.json.gz", "c.yaml",
+		".hidden.json", ".git/config.json",
+		"sub/x.json", "sub/deep/y.json", "sub/deep/z.txt", "sub/.cache/w.json",
+	)
+	t.Chdir(root)
+
+	tests := []struct {
+		name   string
+		filter inputFilter
+		args   []string
+		want   []string
+	}{
+		{
+			name: "directory",
+			args: []string{"."},
+			want: []string{"a.json", "b.json", "data.json.gz"},
+		},
+		{
+			name:   "recursive directory skips hidden files",
+			filter: inputFilter{recursive: true},
+			args:   []string{"."},
+			want:   []string{"a.json", "b.json", "data.json.gz", "sub/deep/y.json", "sub/x.json"},
+		},
+		{
+			name: "double star",
+			args: []string{"**/*.json"},
+			want: []string{"a.json", "b.json", "sub/deep/y.json", "sub/x.json"},
+		},
+		{
+			name: "double star under a directory",
+			args: []string{"sub/**/*"},
+			want: []string{"sub/deep/y.json", "sub/deep/z.txt", "sub/x.json"},
+		},
+		{
+			name: "hidden files match only a leading dot",
+			args: []string{"*", ".*.json"},
+			want: []string{"a.json", "b.json", "c.yaml", "data.json.gz", "notes.txt", ".hidden.json"},
+		},
+		{
+			name:   "include extensions",
+			filter: inputFilter{include: []string{"yaml", "txt"}},
+			args:   []string{"."},
+			want:   []string{"c.yaml", "notes.txt"},
+		},
+		{
+			name:   "exclude extensions",
+			filter: inputFilter{recursive: true, exclude: []string{"gz", ".txt"}},
+			args:   []string{"*", "sub"},
+			want:   []string{"a.json", "b.json", "c.yaml", "sub/deep/y.json", "sub/x.json"},
+		},
+		{
+			name: "files are read once in the order met",
+			args: []string{"./b.json", "b.json", "*.json", "sub/../a.json", "sub/x.json"},
+			want: []string{"./b.json", "a.json", "sub/x.json"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.filter.expand(tt.args)
+			if err != nil {
+				t.Fatalf("expand: %v", err)
+			}
+			for i := range got {
+				got[i] = filepath.ToSlash(got[i])
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, args := range [][]string{{"missing.json"}, {"*.toml"}, {"sub/deep/*.yaml"}} {
+		if _, err := (&inputFilter{}).expand(args); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
+
+func TestMatchSegments(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"**/*.json", "a.json", true},
+		{"**/*.json", "a/b/c.json", true},
+		{"a/**/c.json", "a/c.json", true},
+		{"a/**/c.json", "a/x/y/c.json", true},
+		{"a/**/c.json", "b/x/c.json", false},
+		{"a/*/c.json", "a/x/y/c.json", false},
+		{"**", "a/b", true},
+		{"a/**", "a", true},
+		{"*.json", "a/b.json", false},
+	}
+	for _, tt := range tests {
+		got := matchSegments(splitSlash(tt.pattern), splitSlash(tt.name))
+		if got != tt.want {
+			t.Errorf("%s ~ %s: got %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func splitSlash(s string) []string {
+	return strings.Split(s, "/")
+}
@@ -1,11 +1,18 @@
 package main
 
 import (
+	"bufio"
+	"cmp"
+	"errors"
 	"flag"
+	"fmt"
 	"io"
 	"log"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
 	"unicode"
 
@@ -61,43 +68,384 @@ func parsePathList(s string) ([][]string, error) {
 	return result, nil
 }
 
-func main() {
-	var outPath string
-	var logLevel string
-	var parseObjectsStr string
-	var ignoreObjectsStr string
-	var noStringAnalysis bool
-	var maxDepth int
+// parseNameList parses a space or comma separated list of names
+func parseNameList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+}
 
-	flag.StringVar(&outPath, "out", "", "output file (default stdout)")
-	flag.StringVar(&logLevel, "log-level", "info", "debug|info|warn|error")
-	flag.BoolVar(&noStringAnalysis, "no-string-analysis", false, "will try to additionally detect types like string-uuid, string-email, etc within strings")
-	flag.StringVar(&parseObjectsStr, "parse-objects", "", "space-separated JSON paths to parse (e.g., 'users data.items')")
-	flag.StringVar(&ignoreObjectsStr, "ignore-objects", "", "space-separated JSON paths to ignore (e.g., 'metadata debug.info')")
-	flag.IntVar(&maxDepth, "max-depth", 0, "maximum depth to parse (0 = unlimited)")
-	flag.Parse()
+// buildDetectorSet configures string detectors from command-line parameters
+func buildDetectorSet(only, disable, configPath string) (*jsontype.DetectorSet, error) {
+	detectors := jsontype.DefaultDetectorSet()
 
-	files := make([]string, flag.NArg())
-	for i, arg := range flag.Args() {
-		files[i] = arg
+	if configPath != "" {
+		f, err := os.Open(configPath)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if err := jsontype.LoadDetectorConfig(f, detectors); err != nil {
+			return nil, err
+		}
 	}
 
+	if names := parseNameList(only); len(names) > 0 {
+		if err := detectors.Disable(detectors.Names()...); err != nil {
+			return nil, err
+		}
+		if err := detectors.Enable(names...); err != nil {
+			return nil, err
+		}
+	}
+	if err := detectors.Disable(parseNameList(disable)...); err != nil {
+		return nil, err
+	}
+	return detectors, nil
+}
+
+// parseFlags are the parsing parameters shared by all commands
+type parseFlags struct {
+	logLevel         string
+	parseObjects     string
+	ignoreObjects    string
+	noStringAnalysis bool
+	numberAnalysis   bool
+	detectors        string
+	disableDetectors string
+	detectorsConfig  string
+	listDetectors    bool
+	maxDepth         int
+	ndjson           bool
+	concatenated     bool
+	labelDocuments   bool
+	inputFormat      string
+	limits           jsontype.ParseLimits
+	keepGoing        bool
+	partial          bool
+	jobs             int
+	recursive        bool
+	includeExt       string
+	excludeExt       string
+	members          string
+	excludeMembers   string
+}
+
+func (f *parseFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.logLevel, "log-level", "info", "debug|info|warn|error")
+	fs.BoolVar(&f.noStringAnalysis, "no-string-analysis", false, "will try to additionally detect types like string-uuid, string-email, etc within strings")
+	fs.StringVar(&f.detectors, "detectors", "", "space-separated string detectors to run, all others are disabled (see -list-detectors)")
+	fs.StringVar(&f.disableDetectors, "disable-detectors", "", "space-separated string detectors to disable (e.g., 'hex base64')")
+	fs.StringVar(&f.detectorsConfig, "detectors-config", "", "JSON file with custom regex-based string detectors")
+	fs.BoolVar(&f.listDetectors, "list-detectors", false, "print string detectors in the order they run and exit")
+	fs.BoolVar(&f.numberAnalysis, "number-analysis", false, "detect integers that look like unix timestamps (int-unix-seconds, int-unix-millis, etc)")
+	fs.StringVar(&f.parseObjects, "parse-objects", "", "space-separated JSON paths to parse (e.g., 'users data.items')")
+	fs.StringVar(&f.ignoreObjects, "ignore-objects", "", "space-separated JSON paths to ignore (e.g., 'metadata debug.info')")
+	fs.IntVar(&f.maxDepth, "max-depth", 0, "maximum depth to parse (0 = unlimited)")
+	fs.BoolVar(&f.ndjson, "ndjson", false, "treat every input as newline-delimited JSON (auto-enabled for .ndjson, .jsonl and .ldjson files)")
+	fs.BoolVar(&f.concatenated, "concatenated", false, "read every top-level value of an input as a separate document, e.g. '{...}{...}[...]'")
+	fs.BoolVar(&f.labelDocuments, "label-documents", false, "label concatenated values as <input>#N, N starting at 0 (implies -concatenated)")
+	fs.StringVar(&f.inputFormat, "input-format", "auto", "input format: auto|json|ndjson|yaml|toml|json5|msgpack|cbor|bson, auto picks it by file extension")
+	fs.Int64Var(&f.limits.MaxBytes, "max-bytes", 0, "fail on inputs larger than this many bytes (0 = unlimited)")
+	fs.Int64Var(&f.limits.MaxTokens, "max-tokens", 0, "fail on inputs with more JSON tokens (0 = unlimited)")
+	fs.IntVar(&f.limits.MaxNesting, "max-nesting", 0, "fail on objects and arrays nested deeper (0 = unlimited)")
+	fs.IntVar(&f.limits.MaxObjectKeys, "max-object-keys", 0, "fail on objects with more keys (0 = unlimited)")
+	fs.IntVar(&f.limits.MaxArrayElements, "max-array-elements", 0, "analyze only the first N elements of every array (0 = unlimited)")
+	fs.IntVar(&f.limits.MaxStringLength, "max-string-length", 0, "fail on strings and keys longer than this many bytes (0 = unlimited)")
+	fs.BoolVar(&f.keepGoing, "keep-going", false, "skip inputs that fail to parse, list them at the end and exit with 1")
+	fs.BoolVar(&f.partial, "partial", false, "like -keep-going, but values parsed before the error are still merged")
+	fs.IntVar(&f.jobs, "j", 1, "number of files to parse in parallel (0 = number of CPUs)")
+	fs.BoolVar(&f.recursive, "r", false, "read directory arguments recursively")
+	fs.StringVar(&f.includeExt, "include-ext", "", "space-separated extensions of files to read from directories, globs and archives (default for directories and archives: 'json ndjson jsonl ldjson zip tar tgz')")
+	fs.StringVar(&f.excludeExt, "exclude-ext", "", "space-separated extensions of files to skip in directories, globs and archives")
+	fs.StringVar(&f.members, "members", "", "space-separated glob patterns of archive members to read (e.g., 'api/**/*.json'), patterns without a slash match the base name")
+	fs.StringVar(&f.excludeMembers, "exclude-members", "", "space-separated glob patterns of archive members to skip")
+}
+
+// parseConfig is the parsed form of parseFlags
+type parseConfig struct {
+	opts   jsontype.ParseOptions
+	logger *slog.Logger
+	jobs   int
+	// format of every input, empty means detected by extension
+	format  inputFormat
+	inputs  inputFilter
+	members memberFilter
+	// inputs failing to parse are collected into failures instead of stopping the run
+	keepGoing bool
+	failures  *jsontype.InputErrors
+	// print the string detectors instead of reading inputs
+	listDetectors bool
+}
+
+func (f *parseFlags) config() (*parseConfig, error) {
+	detectors, err := buildDetectorSet(f.detectors, f.disableDetectors, f.detectorsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("configure string detectors: %w", err)
+	}
+	if f.jobs < 0 {
+		return nil, fmt.Errorf("invalid number of jobs: %d", f.jobs)
+	}
+	format, err := parseInputFormat(f.inputFormat)
+	if err != nil {
+		return nil, err
+	}
+	concatenated := f.concatenated || f.labelDocuments
+	if concatenated && (f.ndjson || format == formatNDJSON) {
+		return nil, fmt.Errorf("-concatenated can't be combined with NDJSON")
+	}
 	level := slog.LevelInfo
-	if err := level.UnmarshalText([]byte(logLevel)); err != nil {
-		log.Fatalf("invalid log level: %s", logLevel)
+	if err := level.UnmarshalText([]byte(f.logLevel)); err != nil {
+		return nil, fmt.Errorf("invalid log level: %s", f.logLevel)
 	}
 	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
 		Level: level,
 	}))
 
+	// Parse the path lists from command-line parameters
+	parseObjects, err := parsePathList(f.parseObjects)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse objects list: %w", err)
+	}
+	ignoreObjects, err := parsePathList(f.ignoreObjects)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ignore list: %w", err)
+	}
+
+	slog.Debug("configuration",
+		"parseObjects", parseObjects,
+		"ignoreObjects", ignoreObjects,
+		"maxDepth", f.maxDepth)
+
+	inputs := inputFilter{
+		recursive: f.recursive,
+		include:   parseNameList(f.includeExt),
+		exclude:   parseNameList(f.excludeExt),
+	}
+	if format != "" {
+		inputs.defaults = slices.Concat(formatExtensions[format], archiveExtensions)
+	}
+
+	return &parseConfig{
+		opts: jsontype.ParseOptions{
+			ParseObjects:     parseObjects,
+			IgnoreObjects:    ignoreObjects,
+			MaxDepth:         f.maxDepth,
+			Detectors:        detectors,
+			NoStringAnalysis: f.noStringAnalysis,
+			NumberAnalysis:   f.numberAnalysis,
+			NDJSON:           f.ndjson,
+			Concatenated:     concatenated,
+			DocumentLabels:   f.labelDocuments,
+			Limits:           f.limits,
+			Partial:          f.partial,
+			Logger:           logger,
+		},
+		logger: logger,
+		jobs:   cmp.Or(f.jobs, runtime.NumCPU()),
+		format: format,
+		inputs: inputs,
+		members: memberFilter{
+			include: parseNameList(f.members),
+			exclude: parseNameList(f.excludeMembers),
+		},
+		keepGoing:     f.keepGoing || f.partial,
+		failures:      &jsontype.InputErrors{},
+		listDetectors: f.listDetectors,
+	}, nil
+}
+
+// parseFunc parses a single input
+type parseFunc func(r io.Reader, label string) error
+
+// readInput decompresses the input and passes it to parse.
+// Archives are expanded into their members, each read under its own label
+func (c *parseConfig) readInput(r io.ReadCloser, label string, parse parseFunc) error {
+	defer r.Close()
+	input, format, err := decompress(r)
+	if err != nil {
+		return fmt.Errorf("decompress %s: %w", label, err)
+	}
+	defer input.Close()
+	if format != "" {
+		slog.Debug("decompressing input", "label", label, "format", format)
+	}
+
+	br := bufio.NewReader(input)
+	switch detectArchive(br) {
+	case "zip":
+		// uncompressed zip files are read in place
+		file, _ := r.(*os.File)
+		if format != "" {
+			file = nil
+		}
+		return c.readZip(br, file, label, parse)
+	case "tar":
+		return c.readTar(br, label, parse)
+	}
+	return parse(br, label)
+}
+
+// readFile opens the file and reads it under its path
+func (c *parseConfig) readFile(path string, parse parseFunc) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	slog.Debug("reading file", "file", path)
+	return c.tolerate(path, c.readInput(f, path, parse))
+}
+
+// tolerate records the error of the input with -keep-going instead of returning it
+func (c *parseConfig) tolerate(label string, err error) error {
+	if err == nil || !c.keepGoing {
+		return err
+	}
+	c.logger.Warn("skipping input", "label", label, "error", err)
+	c.failures.Add(label, err)
+	return nil
+}
+
+// reportFailures prints the summary of inputs skipped with -keep-going, reporting if there were any
+func (c *parseConfig) reportFailures(w io.Writer) bool {
+	if c.failures.Len() == 0 {
+		return false
+	}
+	c.failures.WriteSummary(w)
+	return true
+}
+
+// mergeJSON returns a parseFunc merging inputs into the merger, see stream
+func (c *parseConfig) mergeJSON(merger *jsontype.Merger) parseFunc {
+	return func(r io.Reader, label string) error {
+		return c.tolerate(label, c.merge(merger, r, label))
+	}
+}
+
+// merge merges a single input into the merger without tolerating errors
+func (c *parseConfig) merge(merger *jsontype.Merger, r io.Reader, label string) error {
+	s, opts, err := c.stream(r, label)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", label, err)
+	}
+	if _, err := jsontype.MergeStreamWithOptions(merger, label, s, opts); err != nil {
+		// parse errors tell the label and the position themselves
+		var parseErr *jsontype.ParseError
+		if !errors.As(err, &parseErr) {
+			err = fmt.Errorf("parse %s: %w", label, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// printSnippet shows the offending line of a parse error
+func printSnippet(w io.Writer, err error) {
+	var parseErr *jsontype.ParseError
+	if errors.As(err, &parseErr) && parseErr.Snippet != "" {
+		fmt.Fprintln(w, parseErr.Snippet)
+	}
+}
+
+// fatal logs the error with its snippet and exits
+func fatal(err error) {
+	log.Print(err)
+	printSnippet(os.Stderr, err)
+	os.Exit(1)
+}
+
+// mergeFiles merges the files into merger, see readFiles
+func (c *parseConfig) mergeFiles(merger *jsontype.Merger, files []string) error {
+	return readFiles(c, merger, files, newRootMerger, func(m *jsontype.Merger, _ string) parseFunc {
+		return c.mergeJSON(m)
+	})
+}
+
+// printDetectors lists string detectors in the order they run
+func (c *parseConfig) printDetectors(w io.Writer) {
+	detectors := c.opts.Detectors
+	for _, name := range detectors.Names() {
+		state := "enabled"
+		if !detectors.IsEnabled(name) {
+			state = "disabled"
+		}
+		fmt.Fprintf(w, "%s\t%s\n", name, state)
+	}
+}
+
+func newRootMerger() *jsontype.Merger {
+	return jsontype.NewMerger([]string{})
+}
+
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "diff":
+			os.Exit(runDiff(os.Args[2:]))
+		case "openapi":
+			os.Exit(runOpenAPI(os.Args[2:]))
+		}
+	}
+
+	var pf parseFlags
+	var outPath string
+	var format string
+	var goPackage string
+	var goType string
+	var tsBranded bool
+	var harMode bool
+
+	pf.register(flag.CommandLine)
+	flag.StringVar(&outPath, "out", "", "output file (default stdout)")
+	flag.StringVar(&format, "format", "tree", "output format: tree|jsonschema|go|typescript")
+	flag.StringVar(&goPackage, "go-package", "main", "package name for -format go")
+	flag.StringVar(&goType, "go-type", "Root", "root type name for -format go|typescript, nested types are named after it")
+	flag.BoolVar(&tsBranded, "ts-branded", false, "declare branded types for extended strings instead of JSDoc @format (-format typescript)")
+	flag.BoolVar(&harMode, "har", false, "read inputs as HAR captures and report schemas per endpoint (auto-enabled when every input is a .har file)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage:\n  %[1]s [flags] [file|dir|glob ...]\n  %[1]s diff [flags] old new\n  %[1]s openapi [flags] [dir|file.har ...]\n\nFlags:\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	switch format {
+	case "tree", "jsonschema", "go", "typescript":
+	default:
+		log.Fatalf("invalid output format: %s", format)
+	}
+
+	cfg, err := pf.config()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.listDetectors {
+		cfg.printDetectors(os.Stdout)
+		return
+	}
+
 	stat, _ := os.Stdin.Stat()
 	hasStdin := stat.Mode()&os.ModeCharDevice == 0
 
-	if !hasStdin && len(files) == 0 {
+	if !hasStdin && flag.NArg() == 0 {
 		flag.Usage()
 		os.Exit(1)
 	}
 
+	if harMode {
+		cfg.inputs.defaults = harExtensions
+	}
+	files, err := cfg.inputs.expand(flag.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.Debug("input files", "count", len(files))
+	if !harMode && !hasStdin && isHARFiles(files) {
+		harMode = true
+	}
+	if harMode && format != "tree" {
+		log.Fatalf("-format %s isn't supported for HAR captures", format)
+	}
+
 	out := os.Stdout
 	if outPath != "" {
 		f, err := os.Create(outPath)
@@ -108,46 +456,65 @@ func main() {
 		out = f
 	}
 
-	// Parse the path lists from command-line parameters
-	parseObjects, err := parsePathList(parseObjectsStr)
-	if err != nil {
-		log.Panicf("Failed to parse objects list: %v", err)
-	}
-	ignoreObjects, err := parsePathList(ignoreObjectsStr)
-	if err != nil {
-		log.Panicf("Failed to parse ignore list: %v", err)
+	if harMode {
+		set, err := cfg.readHAR(files, hasStdin)
+		if err != nil {
+			log.Fatal(err)
+		}
+		set.WriteReport(out)
+		if cfg.reportFailures(os.Stderr) {
+			os.Exit(1)
+		}
+		return
 	}
 
-	slog.Debug("configuration",
-		"parseObjects", parseObjects,
-		"ignoreObjects", ignoreObjects,
-		"maxDepth", maxDepth)
-
 	merger := jsontype.NewMerger([]string{})
 
-	process := func(r io.ReadCloser, label string) {
-		defer r.Close()
-		stream := jsontype.NewJSONStream(r)
-		root, err := jsontype.ParseStream(stream, parseObjects, ignoreObjects, maxDepth, noStringAnalysis, logger)
+	if hasStdin {
+		slog.Debug("reading from stdin")
+		err := cfg.tolerate("stdin", cfg.readInput(io.NopCloser(os.Stdin), "stdin", cfg.mergeJSON(merger)))
 		if err != nil {
-			log.Fatalf("parse %s: %v", label, err)
+			fatal(err)
 		}
-		jsontype.MergeFieldInfo(merger, label, root, logger)
 	}
 
-	if hasStdin {
-		slog.Debug("reading from stdin")
-		process(io.NopCloser(os.Stdin), "stdin")
+	if err := cfg.mergeFiles(merger, files); err != nil {
+		fatal(err)
+	}
+
+	if cfg.opts.NumberAnalysis {
+		jsontype.ResolveEpochTypes(merger)
 	}
 
-	for _, path := range files {
-		f, err := os.Open(path)
+	switch format {
+	case "jsonschema":
+		if err := jsontype.WriteJSONSchema(merger, out); err != nil {
+			log.Fatalf("write json schema: %v", err)
+		}
+	case "go":
+		src, err := jsontype.GenerateGo(merger, jsontype.GoOptions{
+			PackageName: goPackage,
+			RootName:    goType,
+		})
 		if err != nil {
-			log.Fatalf("open %s: %v", path, err)
+			log.Fatalf("generate go: %v", err)
 		}
-		slog.Debug("reading file", "file", path)
-		process(f, path)
+		if _, err := out.Write(src); err != nil {
+			log.Fatalf("write go: %v", err)
+		}
+	case "typescript":
+		src := jsontype.GenerateTypeScript(merger, jsontype.TSOptions{
+			RootName:       goType,
+			BrandedStrings: tsBranded,
+		})
+		if _, err := io.WriteString(out, src); err != nil {
+			log.Fatalf("write typescript: %v", err)
+		}
+	default:
+		jsontype.PrintMergerTree(merger, "", out)
 	}
 
-	jsontype.PrintMergerTree(merger, "", out)
+	if cfg.reportFailures(os.Stderr) {
+		os.Exit(1)
+	}
 }
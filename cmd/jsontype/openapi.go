@@ -0,0 +1,286 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/4nd3r5on/jsontype"
+)
+
+// httpMethods are recognized as method directories of the samples layout
+var httpMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodOptions, http.MethodTrace,
+}
+
+// openAPIManifest maps sample files to endpoints
+type openAPIManifest struct {
+	Samples []struct {
+		// Endpoint is "METHOD /path/{param}"
+		Endpoint string `yaml:"endpoint"`
+		// Status of response samples
+		Status int `yaml:"status"`
+		// Request marks request body samples
+		Request bool `yaml:"request"`
+		// Files, directories and glob patterns relative to the manifest
+		Files []string `yaml:"files"`
+	} `yaml:"samples"`
+}
+
+// openAPISamples are sample files with their endpoints
+type openAPISamples struct {
+	files []string
+	// endpoint by file, HAR captures aren't listed
+	keys map[string]jsontype.EndpointKey
+}
+
+func (s *openAPISamples) add(path string, key jsontype.EndpointKey) error {
+	if existing, exists := s.keys[path]; exists {
+		if existing != key {
+			return fmt.Errorf("%s is a sample of both %s and %s", path, existing, key)
+		}
+		return nil
+	}
+	s.keys[path] = key
+	s.files = append(s.files, path)
+	return nil
+}
+
+// parseEndpoint parses "METHOD /path"
+func parseEndpoint(s string) (method, path string, err error) {
+	method, path, _ = strings.Cut(strings.TrimSpace(s), " ")
+	method = strings.ToUpper(method)
+	path = strings.TrimSpace(path)
+	if !slices.Contains(httpMethods, method) || !strings.HasPrefix(path, "/") {
+		return "", "", fmt.Errorf("invalid endpoint %q, expected \"METHOD /path\"", s)
+	}
+	return method, path, nil
+}
+
+// loadManifest adds samples listed in the manifest
+func (c *parseConfig) loadManifest(samples *openAPISamples, manifestPath string) error {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return err
+	}
+	var manifest openAPIManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("parse manifest %s: %w", manifestPath, err)
+	}
+
+	dir := filepath.Dir(manifestPath)
+	for i, sample := range manifest.Samples {
+		method, path, err := parseEndpoint(sample.Endpoint)
+		if err != nil {
+			return fmt.Errorf("manifest sample %d: %w", i+1, err)
+		}
+		key := jsontype.EndpointKey{Method: method, Path: path, Direction: jsontype.DirectionResponse, Status: sample.Status}
+		switch {
+		case sample.Request:
+			key.Direction, key.Status = jsontype.DirectionRequest, 0
+		case sample.Status == 0:
+			return fmt.Errorf("manifest sample %d: set either status or request", i+1)
+		}
+
+		patterns := make([]string, len(sample.Files))
+		for j, pattern := range sample.Files {
+			if !filepath.IsAbs(pattern) {
+				pattern = filepath.Join(dir, pattern)
+			}
+			patterns[j] = pattern
+		}
+		files, err := c.inputs.expand(patterns)
+		if err != nil {
+			return fmt.Errorf("manifest sample %d: %w", i+1, err)
+		}
+		for _, file := range files {
+			if err := samples.add(file, key); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// layoutEndpoint parses the endpoint of a sample from its directory relative to the layout root:
+// <path segments>/<METHOD>/<status or "request">/<file>
+func layoutEndpoint(rel string) (jsontype.EndpointKey, bool) {
+	dirs := strings.Split(filepath.ToSlash(filepath.Dir(rel)), "/")
+	for i := len(dirs) - 2; i >= 0; i-- {
+		method := strings.ToUpper(dirs[i])
+		if !slices.Contains(httpMethods, method) {
+			continue
+		}
+		key := jsontype.EndpointKey{Method: method, Direction: jsontype.DirectionResponse}
+		if strings.EqualFold(dirs[i+1], "request") {
+			key.Direction = jsontype.DirectionRequest
+		} else if status, err := strconv.Atoi(dirs[i+1]); err == nil && status >= 100 && status <= 599 {
+			key.Status = status
+		} else {
+			continue
+		}
+
+		segments := slices.Clone(dirs[:i])
+		for j, segment := range segments {
+			// ":id" is accepted as well as "{id}"
+			if name, isParam := strings.CutPrefix(segment, ":"); isParam {
+				segments[j] = "{" + name + "}"
+			}
+		}
+		key.Path = "/" + strings.Join(segments, "/")
+		return key, true
+	}
+	return jsontype.EndpointKey{}, false
+}
+
+// loadLayout adds samples found in the directory laid out as <path>/<METHOD>/<status or "request">/<file>
+func (c *parseConfig) loadLayout(samples *openAPISamples, root string) error {
+	filter := c.inputs
+	filter.recursive = true
+	files, err := filter.walkDir(root)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		rel, err := filepath.Rel(root, file)
+		if err != nil {
+			return err
+		}
+		key, ok := layoutEndpoint(rel)
+		if !ok {
+			return fmt.Errorf("%s doesn't follow the <path>/<METHOD>/<status or request>/<file> layout", file)
+		}
+		if err := samples.add(file, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeSamples returns a parseFunc merging samples of the endpoint into the set.
+// Samples skipped with -keep-going aren't counted
+func (c *parseConfig) mergeSamples(set *jsontype.EndpointSet, key jsontype.EndpointKey) parseFunc {
+	return func(r io.Reader, label string) error {
+		endpoint := set.Endpoint(key)
+		if err := c.merge(endpoint.Merger, r, label); err != nil {
+			return c.tolerate(label, err)
+		}
+		endpoint.Samples++
+		return nil
+	}
+}
+
+// runOpenAPI generates an OpenAPI document from samples grouped by endpoint and returns the exit code
+func runOpenAPI(args []string) int {
+	fs := flag.NewFlagSet("openapi", flag.ContinueOnError)
+
+	var pf parseFlags
+	var outPath string
+	var format string
+	var manifestPath string
+	var opts jsontype.OpenAPIOptions
+
+	pf.register(fs)
+	fs.StringVar(&outPath, "out", "", "output file (default stdout)")
+	fs.StringVar(&format, "format", "yaml", "output format: yaml|json")
+	fs.StringVar(&manifestPath, "manifest", "", "YAML or JSON file mapping sample files to endpoints")
+	fs.StringVar(&opts.Title, "title", "Inferred API", "title of the API")
+	fs.StringVar(&opts.Version, "api-version", "1.0.0", "version of the API")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage:\n  %[1]s openapi [flags] [dir|file.har ...]\n  %[1]s openapi [flags] -manifest samples.yaml\n\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(fs.Output(), "Directories are laid out as <path>/<METHOD>/<status or request>/<file>, e.g. users/{id}/GET/200/alice.json\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	fail := func(err error) int {
+		fmt.Fprintf(os.Stderr, "jsontype openapi: %v\n", err)
+		printSnippet(os.Stderr, err)
+		return 1
+	}
+
+	cfg, err := pf.config()
+	if err != nil {
+		return fail(err)
+	}
+	if cfg.listDetectors {
+		cfg.printDetectors(os.Stdout)
+		return 0
+	}
+
+	switch format {
+	case "yaml", "json":
+	default:
+		return fail(fmt.Errorf("invalid output format: %s", format))
+	}
+	if manifestPath == "" && fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	samples := &openAPISamples{keys: make(map[string]jsontype.EndpointKey)}
+	if manifestPath != "" {
+		if err := cfg.loadManifest(samples, manifestPath); err != nil {
+			return fail(err)
+		}
+	}
+	var captures []string
+	for _, arg := range fs.Args() {
+		info, err := os.Stat(arg)
+		switch {
+		case err == nil && info.IsDir():
+			err = cfg.loadLayout(samples, arg)
+		case err == nil && isHARFiles([]string{arg}):
+			captures = append(captures, arg)
+		case err == nil:
+			err = fmt.Errorf("can't tell the endpoint of %s, use a directory layout or -manifest", arg)
+		}
+		if err != nil {
+			return fail(err)
+		}
+	}
+
+	set := jsontype.NewEndpointSet()
+	parser := func(set *jsontype.EndpointSet, path string) parseFunc {
+		if key, exists := samples.keys[path]; exists {
+			return cfg.mergeSamples(set, key)
+		}
+		return cfg.mergeHAR(set)
+	}
+	if err := readFiles(cfg, set, append(samples.files, captures...), jsontype.NewEndpointSet, parser); err != nil {
+		return fail(err)
+	}
+	if cfg.opts.NumberAnalysis {
+		for _, e := range set.Endpoints() {
+			jsontype.ResolveEpochTypes(e.Merger)
+		}
+	}
+
+	out := os.Stdout
+	if outPath != "" {
+		f, err := os.Create(outPath)
+		if err != nil {
+			return fail(fmt.Errorf("open output: %w", err))
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := jsontype.WriteOpenAPI(set, out, format == "json", opts); err != nil {
+		return fail(fmt.Errorf("write openapi: %w", err))
+	}
+	if cfg.reportFailures(os.Stderr) {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/4nd3r5on/jsontype"
+)
+
+func TestMergeSamples_KeepGoing(t *testing.T) {
+	c := testConfig()
+	c.keepGoing = true
+	set := jsontype.NewEndpointSet()
+	key := jsontype.EndpointKey{Method: "GET", Path: "/users", Direction: jsontype.DirectionResponse, Status: 200}
+
+	merge := c.mergeSamples(set, key)
+	for label, sample := range map[string]string{
+		"alice.json":  `{"id": 1}`,
+		"broken.json": `{"id": `,
+		"bob.json":    `{"id": 2}`,
+	} {
+		if err := merge(strings.NewReader(sample), label); err != nil {
+			t.Fatalf("%s: %v", label, err)
+		}
+	}
+
+	if n := set.Endpoint(key).Samples; n != 2 {
+		t.Errorf("skipped samples must not be counted, got %d samples", n)
+	}
+	if n := c.failures.Len(); n != 1 {
+		t.Errorf("expected 1 failure, got %d", n)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import "sync"
+
+// mergeable results of reading files
+type mergeable[T any] interface {
+	Merge(other T)
+}
+
+// fileResult is a file read into its own result
+type fileResult[T any] struct {
+	index  int
+	result T
+	err    error
+}
+
+// readFiles reads the files into result, up to c.jobs of them in parallel.
+// Every file is read into a separate value created by newResult and they are merged
+// in the order of files, so the result (and the reported error) doesn't depend on scheduling.
+// parser returns the parseFunc reading the file into a result
+func readFiles[T mergeable[T]](c *parseConfig, result T, files []string, newResult func() T, parser func(result T, path string) parseFunc) error {
+	if c.jobs <= 1 || len(files) <= 1 {
+		for _, path := range files {
+			if err := c.readFile(path, parser(result, path)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	// bounds the number of read files waiting for the slower ones before them
+	window := make(chan struct{}, 2*c.jobs)
+	indices := make(chan int)
+	results := make(chan fileResult[T])
+
+	go func() {
+		defer close(indices)
+		for i := range files {
+			select {
+			case window <- struct{}{}:
+			case <-done:
+				return
+			}
+			select {
+			case indices <- i:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for range min(c.jobs, len(files)) {
+		wg.Go(func() {
+			for i := range indices {
+				fileResult := fileResult[T]{index: i, result: newResult()}
+				fileResult.err = c.readFile(files[i], parser(fileResult.result, files[i]))
+				select {
+				case results <- fileResult:
+				case <-done:
+					return
+				}
+			}
+		})
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	pending := make(map[int]fileResult[T])
+	next := 0
+	for r := range results {
+		pending[r.index] = r
+		for {
+			r, exists := pending[next]
+			if !exists {
+				break
+			}
+			delete(pending, next)
+			if r.err != nil {
+				return r.err
+			}
+			result.Merge(r.result)
+			<-window
+			next++
+		}
+	}
+	return nil
+}
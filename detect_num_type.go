@@ -0,0 +1,192 @@
+package jsontype
+
+import (
+	"strconv"
+	"strings"
+)
+
+// DecimalInfo holds the widest digits of numbers written with a fraction or an exponent,
+// e.g. to pick DECIMAL(precision, scale) or to tell if float64 keeps every digit
+type DecimalInfo struct {
+	// most digits before the decimal point
+	IntegerDigits int
+	// most digits after the decimal point, trailing zeros included
+	Scale int
+}
+
+// Precision is the number of digits needed to hold every number
+func (d DecimalInfo) Precision() int {
+	return d.IntegerDigits + d.Scale
+}
+
+// IsZero reports if no decimal numbers were met
+func (d DecimalInfo) IsZero() bool {
+	return d == DecimalInfo{}
+}
+
+// Widen returns digits holding both d and other
+func (d DecimalInfo) Widen(other DecimalInfo) DecimalInfo {
+	return DecimalInfo{
+		IntegerDigits: max(d.IntegerDigits, other.IntegerDigits),
+		Scale:         max(d.Scale, other.Scale),
+	}
+}
+
+// DetectNumberLiteral classifies a JSON number as it is written.
+// Integers get the narrowest of int32, int64, uint64 and bigint,
+// numbers with a fraction or an exponent (even 1.0) are float64 with their digits
+func DetectNumberLiteral(s string) (DetectedType, DecimalInfo) {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if i >= -2147483648 && i <= 2147483647 {
+			return TypeInt32, DecimalInfo{}
+		}
+		return TypeInt64, DecimalInfo{}
+	}
+	if _, err := strconv.ParseUint(s, 10, 64); err == nil {
+		return TypeUint64, DecimalInfo{}
+	}
+	if isIntegerLiteral(s) {
+		return TypeBigInt, DecimalInfo{}
+	}
+	return TypeFloat64, decimalDigits(s)
+}
+
+func isIntegerLiteral(s string) bool {
+	return isNumeric(strings.TrimPrefix(s, "-"))
+}
+
+// isDecimalLiteral reports if s is written as a JSON number: digits, a fraction and an exponent
+func isDecimalLiteral(s string) bool {
+	mantissa, exponent, hasExponent := strings.Cut(strings.ToLower(strings.TrimPrefix(s, "-")), "e")
+	if hasExponent && !isNumeric(strings.TrimLeft(exponent, "+-")) {
+		return false
+	}
+	intPart, frac, hasFrac := strings.Cut(mantissa, ".")
+	return isNumeric(intPart) && (!hasFrac || isNumeric(frac))
+}
+
+// decimalDigits counts digits of a number with a fraction or an exponent: 1.50 has scale 2,
+// 1.5e3 has 4 integer digits, 1.5e-3 has scale 4
+func decimalDigits(s string) DecimalInfo {
+	s = strings.TrimLeft(s, "+-")
+	mantissa, exponent, hasExponent := strings.Cut(strings.ToLower(s), "e")
+	exp := 0
+	if hasExponent {
+		var err error
+		if exp, err = strconv.Atoi(exponent); err != nil {
+			return DecimalInfo{}
+		}
+	}
+	intPart, frac, _ := strings.Cut(mantissa, ".")
+
+	// position of the decimal point in the significant digits
+	digits := intPart + frac
+	point := len(intPart) + exp
+	trimmed := strings.TrimLeft(digits, "0")
+	if trimmed == "" {
+		// zero keeps the written scale
+		return DecimalInfo{Scale: max(len(frac)-exp, 0)}
+	}
+	point -= len(digits) - len(trimmed)
+	return DecimalInfo{
+		IntegerDigits: max(point, 0),
+		Scale:         max(len(trimmed)-point, 0),
+	}
+}
+
+// Plausible unix timestamps: from 2000-01-01 to 2100-01-01 in seconds.
+// Ranges for other units don't overlap, so a value can't match two of them
+const (
+	epochSecondsMin = 946684800
+	epochSecondsMax = 4102444800
+)
+
+var epochUnits = []struct {
+	Type  DetectedType
+	Scale float64
+}{
+	{TypeUnixSeconds, 1},
+	{TypeUnixMillis, 1e3},
+	{TypeUnixMicros, 1e6},
+	{TypeUnixNanos, 1e9},
+}
+
+// DetectEpochType detects integers that look like unix timestamps
+func DetectEpochType(f float64) (DetectedType, bool) {
+	if f != float64(int64(f)) {
+		return "", false
+	}
+	for _, unit := range epochUnits {
+		if f >= epochSecondsMin*unit.Scale && f < epochSecondsMax*unit.Scale {
+			return unit.Type, true
+		}
+	}
+	return "", false
+}
+
+// IsEpochType returns true for unix timestamp types
+func IsEpochType(t DetectedType) bool {
+	for _, unit := range epochUnits {
+		if unit.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolveEpochTypes keeps unix timestamp types only on paths where all numbers agree on
+// the same unit. Anywhere else they are demoted to the integer type they were read as,
+// so a counter that happened to hit the timestamp range isn't reported as a timestamp.
+// Call it once all the inputs are merged
+func ResolveEpochTypes(m *Merger) {
+	if m == nil {
+		return
+	}
+
+	var epochs, others int
+	for t := range m.TypesMap {
+		switch {
+		case IsEpochType(t):
+			epochs++
+		case IsNumberType(t):
+			others++
+		}
+	}
+	if epochs > 1 || epochs == 1 && others > 0 {
+		total := make(map[DetectedType]int)
+		for label, types := range m.LabeledTypesMap {
+			demoteEpochTypes(types, m.epochIntTypes[label])
+			for t, n := range m.epochIntTypes[label] {
+				total[t] += n
+			}
+		}
+		demoteEpochTypes(m.TypesMap, total)
+		m.epochIntTypes = nil
+	}
+
+	for _, key := range m.ChildrenKeys {
+		ResolveEpochTypes(m.ChildrenMap[key])
+	}
+}
+
+// demoteEpochTypes replaces timestamp types with the integer types they were read as,
+// timestamps of unknown type become int64
+func demoteEpochTypes(types, intTypes map[DetectedType]int) {
+	epochs := 0
+	for t, n := range types {
+		if IsEpochType(t) {
+			epochs += n
+			delete(types, t)
+		}
+	}
+	for _, t := range []DetectedType{TypeInt32, TypeInt64} {
+		n := min(intTypes[t], epochs)
+		if n > 0 {
+			types[t] += n
+			epochs -= n
+		}
+	}
+	if epochs > 0 {
+		types[TypeInt64] += epochs
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+	"time"
 	"unicode/utf8"
 )
 
@@ -19,6 +20,10 @@ var (
 	reDomain    = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,}$`)
 	reHexStrict = regexp.MustCompile(`^[0-9a-f]+$`)
 
+	reDateTime = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}[Tt ]\d{2}:\d{2}(:\d{2}(\.\d+)?)?([Zz]|[+-]\d{2}:?\d{2})?$`)
+	reTime     = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}(\.\d+)?([Zz]|[+-]\d{2}:\d{2})?$`)
+	reDuration = regexp.MustCompile(`^P(\d+Y)?(\d+M)?(\d+W)?(\d+D)?(T(\d+H)?(\d+M)?(\d+([.,]\d+)?S)?)?$`)
+
 	reBase64Std    = regexp.MustCompile(`^(?:[A-Za-z0-9+/]{4})*(?:[A-Za-z0-9+/]{2}==|[A-Za-z0-9+/]{3}=)?$`)
 	reBase64RawStd = regexp.MustCompile(`^(?:[A-Za-z0-9+/]{4})*(?:[A-Za-z0-9+/]{2,3})?$`)
 	reBase64URL    = regexp.MustCompile(`^(?:[A-Za-z0-9_-]{4})*(?:[A-Za-z0-9_-]{2}==|[A-Za-z0-9_-]{3}=)?$`)
@@ -59,50 +64,46 @@ var Base64Variants = []Base64Variant{
 	},
 }
 
-type detector func(string) (DetectedType, bool)
-
-// Ordered list of detectors: specific → generic, cheap → expensive
-var detectors = []detector{
+// Built-in detectors: specific → generic, cheap → expensive.
+// Priorities are spaced so custom detectors can be placed in between
+var builtinDetectors = []Detector{
 	// 1. Networking (highest priority, most specific)
-	detectIPv6PortPair,
-	detectIPv4PortPair,
-	detectIPv4WithMask,
-	detectIPv6,
-	detectIPv4,
-	detectMAC,
+	{Name: "ipv6-port-pair", Priority: 100, Detect: detectIPv6PortPair},
+	{Name: "ipv4-port-pair", Priority: 200, Detect: detectIPv4PortPair},
+	{Name: "ipv4-with-mask", Priority: 300, Detect: detectIPv4WithMask},
+	{Name: "ipv6", Priority: 400, Detect: detectIPv6},
+	{Name: "ipv4", Priority: 500, Detect: detectIPv4},
+	{Name: "mac", Priority: 600, Detect: detectMAC},
 
 	// 2. Identifiers (UUID must come before base64url!)
-	detectUUID,
-	detectEmail,
-	detectPhone,
-
-	// 3. URLs and domains
-	detectLink,
-	detectDomain,
-
-	// 4. Encodings (must come after UUID and networking)
-	DetectHex,
-	DetectBase64,
-
-	// 5. Paths
-	detectWindowsPath,
+	{Name: "uuid", Priority: 700, Detect: detectUUID},
+	{Name: "email", Priority: 800, Detect: detectEmail},
+	{Name: "phone", Priority: 900, Detect: detectPhone},
+
+	// 3. Dates and times (must come before encodings, "20240131" is valid hex)
+	{Name: "datetime", Priority: 1000, Detect: detectDateTime},
+	{Name: "date", Priority: 1100, Detect: detectDate},
+	{Name: "time", Priority: 1200, Detect: detectTime},
+	{Name: "datetime-rfc1123", Priority: 1300, Detect: detectRFC1123},
+	{Name: "duration", Priority: 1400, Detect: detectDuration},
+
+	// 4. URLs and domains
+	{Name: "link", Priority: 1500, Detect: detectLink},
+	{Name: "domain", Priority: 1600, Detect: detectDomain},
+
+	// 5. Encodings (must come after UUID, networking and dates)
+	{Name: "hex", Priority: 1700, Detect: DetectHex},
+	{Name: "base64", Priority: 1800, Detect: DetectBase64},
+
+	// 6. Paths
+	{Name: "windows-path", Priority: 1900, Detect: detectWindowsPath},
 }
 
-// DetectStrType detects the type of a string value
-func DetectStrType(s string) DetectedType {
-	// Trivial rejection
-	if s == "" {
-		return TypeString
-	}
+var defaultDetectors = DefaultDetectorSet()
 
-	// Run detectors in order, first match wins
-	for _, detect := range detectors {
-		if typ, ok := detect(s); ok {
-			return typ
-		}
-	}
-
-	return TypeString
+// DetectStrType detects the type of a string value with the built-in detectors
+func DetectStrType(s string) DetectedType {
+	return defaultDetectors.Detect(s)
 }
 
 // ============= Networking Detectors =============
@@ -232,6 +233,77 @@ func detectPhone(s string) (DetectedType, bool) {
 	return "", false
 }
 
+// ============= Date/Time Detectors =============
+
+func detectDateTime(s string) (DetectedType, bool) {
+	if len(s) < 16 || len(s) > 40 || !reDateTime.MatchString(s) {
+		return "", false
+	}
+	// RFC 3339 requires seconds, a "T" separator and a time zone
+	if _, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return TypeDateTimeRFC3339, true
+	}
+	// ISO 8601 allows omitting the zone (local time) and the seconds
+	normalized := strings.Replace(strings.ToUpper(s), " ", "T", 1)
+	for _, layout := range []string{
+		"2006-01-02T15:04:05.999999999Z07:00",
+		"2006-01-02T15:04:05.999999999Z0700",
+		"2006-01-02T15:04:05.999999999",
+		"2006-01-02T15:04Z07:00",
+		"2006-01-02T15:04",
+	} {
+		if _, err := time.Parse(layout, normalized); err == nil {
+			return TypeDateTimeISO8601, true
+		}
+	}
+	return "", false
+}
+
+func detectDate(s string) (DetectedType, bool) {
+	if len(s) != 10 || s[4] != '-' || s[7] != '-' {
+		return "", false
+	}
+	if _, err := time.Parse(time.DateOnly, s); err == nil {
+		return TypeDate, true
+	}
+	return "", false
+}
+
+func detectTime(s string) (DetectedType, bool) {
+	if len(s) < 8 || s[2] != ':' || !reTime.MatchString(s) {
+		return "", false
+	}
+	// validates ranges of hours, minutes and seconds
+	if _, err := time.Parse("15:04:05", s[:8]); err == nil {
+		return TypeTime, true
+	}
+	return "", false
+}
+
+func detectRFC1123(s string) (DetectedType, bool) {
+	if len(s) < 25 || len(s) > 31 || s[3] != ',' {
+		return "", false
+	}
+	if _, err := time.Parse(time.RFC1123, s); err == nil {
+		return TypeDateTimeRFC1123, true
+	}
+	if _, err := time.Parse(time.RFC1123Z, s); err == nil {
+		return TypeDateTimeRFC1123, true
+	}
+	return "", false
+}
+
+func detectDuration(s string) (DetectedType, bool) {
+	// "P" and "PT" alone are not durations
+	if len(s) < 3 || s[0] != 'P' || strings.HasSuffix(s, "T") {
+		return "", false
+	}
+	if reDuration.MatchString(s) {
+		return TypeDuration, true
+	}
+	return "", false
+}
+
 // ============= URL/Domain Detectors =============
 
 func detectLink(s string) (DetectedType, bool) {
@@ -81,3 +81,31 @@ func Test_detectBase64_randomPools(t *testing.T) {
 		}
 	})
 }
+
+func TestDetectStrType_DatesAndTimes(t *testing.T) {
+	tests := map[string]jsontype.DetectedType{
+		"2024-01-31T10:00:00Z":            jsontype.TypeDateTimeRFC3339,
+		"2024-01-31T10:00:00.123+02:00":   jsontype.TypeDateTimeRFC3339,
+		"2024-01-31T10:00:00":             jsontype.TypeDateTimeISO8601,
+		"2024-01-31 10:00":                jsontype.TypeDateTimeISO8601,
+		"Wed, 31 Jan 2024 10:00:00 GMT":   jsontype.TypeDateTimeRFC1123,
+		"Wed, 31 Jan 2024 10:00:00 +0200": jsontype.TypeDateTimeRFC1123,
+		"2024-01-31":                      jsontype.TypeDate,
+		"10:00:00":                        jsontype.TypeTime,
+		"10:00:00.5Z":                     jsontype.TypeTime,
+		"PT5M":                            jsontype.TypeDuration,
+		"P1Y2M3DT4H5M6.5S":                jsontype.TypeDuration,
+		"P2W":                             jsontype.TypeDuration,
+		// negatives
+		"2024-13-31": jsontype.TypeString,
+		"25:00:00":   jsontype.TypeString,
+		"PT":         jsontype.TypeString,
+		"PRICE":      jsontype.TypeString,
+		"20240131":   jsontype.TypeHEX,
+	}
+	for in, want := range tests {
+		if got := jsontype.DetectStrType(in); got != want {
+			t.Errorf("DetectStrType(%q) = %s, expected %s", in, got, want)
+		}
+	}
+}
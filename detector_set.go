@@ -0,0 +1,214 @@
+package jsontype
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+)
+
+// StringDetector reports the type of a string if it recognizes its format
+type StringDetector func(string) (DetectedType, bool)
+
+// Detector is a named string detector.
+// Detectors with lower priority run first
+type Detector struct {
+	Name     string
+	Priority int
+	Detect   StringDetector
+}
+
+// DetectorSet is an ordered set of string detectors, the first match wins.
+// A nil *DetectorSet disables string analysis.
+// The set must not be modified while it's used for parsing
+type DetectorSet struct {
+	detectors []Detector
+	disabled  map[string]struct{}
+}
+
+// NewDetectorSet creates a set with the given detectors
+func NewDetectorSet(detectors ...Detector) (*DetectorSet, error) {
+	s := &DetectorSet{disabled: make(map[string]struct{})}
+	for _, d := range detectors {
+		if err := s.Register(d); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// DefaultDetectorSet returns a new set with all the built-in detectors
+func DefaultDetectorSet() *DetectorSet {
+	return &DetectorSet{
+		detectors: slices.Clone(builtinDetectors),
+		disabled:  make(map[string]struct{}),
+	}
+}
+
+// Register adds a detector. Detectors with equal priority run in the order of registration
+func (s *DetectorSet) Register(d Detector) error {
+	if d.Name == "" {
+		return fmt.Errorf("detector name is empty")
+	}
+	if d.Detect == nil {
+		return fmt.Errorf("detector %q has no detect function", d.Name)
+	}
+	if s.index(d.Name) >= 0 {
+		return fmt.Errorf("detector %q is already registered", d.Name)
+	}
+
+	i := len(s.detectors)
+	for i > 0 && s.detectors[i-1].Priority > d.Priority {
+		i--
+	}
+	s.detectors = slices.Insert(s.detectors, i, d)
+	return nil
+}
+
+// RegisterBefore adds a detector that runs right before an already registered one
+func (s *DetectorSet) RegisterBefore(name string, detect StringDetector, before string) error {
+	priority, ok := s.Priority(before)
+	if !ok {
+		return fmt.Errorf("unknown detector %q", before)
+	}
+	return s.Register(Detector{Name: name, Priority: priority - 1, Detect: detect})
+}
+
+// RegisterAfter adds a detector that runs right after an already registered one
+func (s *DetectorSet) RegisterAfter(name string, detect StringDetector, after string) error {
+	priority, ok := s.Priority(after)
+	if !ok {
+		return fmt.Errorf("unknown detector %q", after)
+	}
+	return s.Register(Detector{Name: name, Priority: priority, Detect: detect})
+}
+
+// Disable turns detectors off without removing them from the set
+func (s *DetectorSet) Disable(names ...string) error {
+	for _, name := range names {
+		if s.index(name) < 0 {
+			return fmt.Errorf("unknown detector %q", name)
+		}
+		s.disabled[name] = struct{}{}
+	}
+	return nil
+}
+
+// Enable turns previously disabled detectors back on
+func (s *DetectorSet) Enable(names ...string) error {
+	for _, name := range names {
+		if s.index(name) < 0 {
+			return fmt.Errorf("unknown detector %q", name)
+		}
+		delete(s.disabled, name)
+	}
+	return nil
+}
+
+// Priority returns the priority of a registered detector
+func (s *DetectorSet) Priority(name string) (int, bool) {
+	i := s.index(name)
+	if i < 0 {
+		return 0, false
+	}
+	return s.detectors[i].Priority, true
+}
+
+// Names returns names of all registered detectors in the order they run
+func (s *DetectorSet) Names() []string {
+	names := make([]string, 0, len(s.detectors))
+	for _, d := range s.detectors {
+		names = append(names, d.Name)
+	}
+	return names
+}
+
+// IsEnabled reports if a registered detector is enabled
+func (s *DetectorSet) IsEnabled(name string) bool {
+	_, disabled := s.disabled[name]
+	return s.index(name) >= 0 && !disabled
+}
+
+// Detect runs enabled detectors in order, the first match wins
+func (s *DetectorSet) Detect(str string) DetectedType {
+	// Trivial rejection
+	if s == nil || str == "" {
+		return TypeString
+	}
+	for _, d := range s.detectors {
+		if _, disabled := s.disabled[d.Name]; disabled {
+			continue
+		}
+		if typ, ok := d.Detect(str); ok {
+			return typ
+		}
+	}
+	return TypeString
+}
+
+func (s *DetectorSet) index(name string) int {
+	return slices.IndexFunc(s.detectors, func(d Detector) bool {
+		return d.Name == name
+	})
+}
+
+// NewRegexDetector creates a detector reporting t for strings matching re
+func NewRegexDetector(t DetectedType, re *regexp.Regexp) StringDetector {
+	return func(s string) (DetectedType, bool) {
+		if re.MatchString(s) {
+			return t, true
+		}
+		return "", false
+	}
+}
+
+// DetectorConfig describes a regex-based custom detector.
+// Placement is chosen by Before, After or Priority (in that order),
+// detectors without any run after the built-ins
+type DetectorConfig struct {
+	Name     string       `json:"name"`
+	Type     DetectedType `json:"type"`
+	Pattern  string       `json:"pattern"`
+	Before   string       `json:"before,omitempty"`
+	After    string       `json:"after,omitempty"`
+	Priority *int         `json:"priority,omitempty"`
+}
+
+// LoadDetectorConfig reads a JSON array of DetectorConfig and registers the detectors in the set
+func LoadDetectorConfig(r io.Reader, s *DetectorSet) error {
+	var configs []DetectorConfig
+	if err := json.NewDecoder(r).Decode(&configs); err != nil {
+		return fmt.Errorf("failed to decode detector config: %w", err)
+	}
+
+	for _, cfg := range configs {
+		if cfg.Type == "" {
+			return fmt.Errorf("detector %q has no type", cfg.Name)
+		}
+		re, err := regexp.Compile(cfg.Pattern)
+		if err != nil {
+			return fmt.Errorf("detector %q has invalid pattern: %w", cfg.Name, err)
+		}
+		detect := NewRegexDetector(cfg.Type, re)
+
+		switch {
+		case cfg.Before != "":
+			err = s.RegisterBefore(cfg.Name, detect, cfg.Before)
+		case cfg.After != "":
+			err = s.RegisterAfter(cfg.Name, detect, cfg.After)
+		case cfg.Priority != nil:
+			err = s.Register(Detector{Name: cfg.Name, Priority: *cfg.Priority, Detect: detect})
+		default:
+			last := 0
+			if len(s.detectors) > 0 {
+				last = s.detectors[len(s.detectors)-1].Priority
+			}
+			err = s.Register(Detector{Name: cfg.Name, Priority: last, Detect: detect})
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
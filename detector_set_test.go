@@ -0,0 +1,65 @@
+package jsontype_test
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/4nd3r5on/jsontype"
+)
+
+func TestDetectorSet(t *testing.T) {
+	set := jsontype.DefaultDetectorSet()
+
+	// "deadbeef" is valid hex, a custom detector placed before it must win
+	orderID := jsontype.NewRegexDetector("string-order-id", regexp.MustCompile(`^[0-9a-f]{8}$`))
+	if err := set.RegisterBefore("order-id", orderID, "hex"); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if got := set.Detect("deadbeef"); got != "string-order-id" {
+		t.Errorf("expected custom detector to run before hex, got %s", got)
+	}
+	if err := set.RegisterBefore("order-id", orderID, "hex"); err == nil {
+		t.Errorf("expected an error for a duplicate detector name")
+	}
+
+	if err := set.Disable("order-id", "uuid"); err != nil {
+		t.Fatalf("disable: %v", err)
+	}
+	if got := set.Detect("deadbeef"); got != jsontype.TypeHEX {
+		t.Errorf("expected hex once the custom detector is disabled, got %s", got)
+	}
+	if got := set.Detect("f3a9c2e7-6b4d-4f81-9a6c-2d8e5b71c0fa"); got == jsontype.TypeUUID {
+		t.Errorf("uuid detector is disabled")
+	}
+	if err := set.Disable("no-such-detector"); err == nil {
+		t.Errorf("expected an error for an unknown detector")
+	}
+
+	var disabled *jsontype.DetectorSet
+	if got := disabled.Detect("deadbeef"); got != jsontype.TypeString {
+		t.Errorf("nil set must disable string analysis, got %s", got)
+	}
+}
+
+func TestLoadDetectorConfig(t *testing.T) {
+	set := jsontype.DefaultDetectorSet()
+	config := `[
+		{"name": "sku", "type": "string-sku", "pattern": "^[A-Z]{3}-[0-9]{4}$", "before": "uuid"},
+		{"name": "tenant", "type": "string-tenant", "pattern": "^t-[a-z]+$"}
+	]`
+	if err := jsontype.LoadDetectorConfig(strings.NewReader(config), set); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	names := set.Names()
+	if names[len(names)-1] != "tenant" {
+		t.Errorf("detectors without placement must run last, got order %v", names)
+	}
+	if got := set.Detect("ABC-1234"); got != "string-sku" {
+		t.Errorf("expected string-sku, got %s", got)
+	}
+	if got := set.Detect("t-acme"); got != "string-tenant" {
+		t.Errorf("expected string-tenant, got %s", got)
+	}
+}
@@ -0,0 +1,213 @@
+package jsontype
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// ChangeKind describes what changed at a path between two merged trees
+type ChangeKind string
+
+const (
+	ChangePathAdded      ChangeKind = "path-added"
+	ChangePathRemoved    ChangeKind = "path-removed"
+	ChangeTypesAdded     ChangeKind = "types-added"
+	ChangeTypesRemoved   ChangeKind = "types-removed"
+	ChangeBecameNullable ChangeKind = "became-nullable"
+	ChangeNoLongerNull   ChangeKind = "no-longer-nullable"
+	ChangeBecameOptional ChangeKind = "became-optional"
+	ChangeBecameRequired ChangeKind = "became-required"
+	ChangeArrayStrategy  ChangeKind = "array-strategy"
+)
+
+// Change is a single difference found at a path
+type Change struct {
+	Kind ChangeKind `json:"kind"`
+	Path []string   `json:"path"`
+	// types added, removed, or met at an added/removed path
+	Types []DetectedType `json:"types,omitempty"`
+	// old and new array strategies for ChangeArrayStrategy
+	Old string `json:"old,omitempty"`
+	New string `json:"new,omitempty"`
+}
+
+func (c Change) String() string {
+	path := PathToString(c.Path)
+	types := strings.Join(TypesToString(c.Types), ", ")
+	switch c.Kind {
+	case ChangePathAdded:
+		return fmt.Sprintf("+ %s (%s)", path, types)
+	case ChangePathRemoved:
+		return fmt.Sprintf("- %s (%s)", path, types)
+	case ChangeTypesAdded:
+		return fmt.Sprintf("~ %s: types added: %s", path, types)
+	case ChangeTypesRemoved:
+		return fmt.Sprintf("~ %s: types removed: %s", path, types)
+	case ChangeArrayStrategy:
+		return fmt.Sprintf("~ %s: array strategy %s -> %s", path, c.Old, c.New)
+	}
+	return fmt.Sprintf("~ %s: %s", path, strings.ReplaceAll(string(c.Kind), "-", " "))
+}
+
+// SchemaDiff lists changes between two merged trees sorted by path,
+// parents go before children
+type SchemaDiff struct {
+	Changes []Change `json:"changes"`
+}
+
+// HasChanges reports if the trees differ
+func (d *SchemaDiff) HasChanges() bool {
+	return len(d.Changes) > 0
+}
+
+// WriteText writes one change per line
+func (d *SchemaDiff) WriteText(w io.Writer) error {
+	for _, c := range d.Changes {
+		if _, err := fmt.Fprintln(w, c.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the diff as an indented JSON document
+func (d *SchemaDiff) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// DiffMergers compares two merged trees.
+// Added and removed subtrees are reported once at their root,
+// children of arrays that changed the strategy aren't compared
+func DiffMergers(before, after *Merger) *SchemaDiff {
+	d := &SchemaDiff{Changes: make([]Change, 0)}
+	d.diffNode(before, after)
+	// keep the output stable for CI, children order depends on the input
+	slices.SortStableFunc(d.Changes, func(a, b Change) int {
+		return slices.Compare(a.Path, b.Path)
+	})
+	return d
+}
+
+// DiffLabels compares data merged under two labels of the same tree
+func DiffLabels(m *Merger, oldLabel, newLabel string) *SchemaDiff {
+	return DiffMergers(FilterByLabel(m, oldLabel), FilterByLabel(m, newLabel))
+}
+
+// FilterByLabel returns a copy of the tree with only the types met under the label.
+// Paths the label never reached are dropped, presence isn't tracked per label
+// so the copy has no optional keys
+func FilterByLabel(m *Merger, label string) *Merger {
+	out := NewMerger(m.Path)
+	for t, n := range m.LabeledTypesMap[label] {
+		out.AddTypeCount(label, t, n)
+	}
+	for t, n := range m.epochIntTypes[label] {
+		out.addEpochIntType(label, t, n)
+	}
+	if out.TypesMap[TypeFloat64] > 0 {
+		// digits aren't tracked per label
+		out.Decimal = m.Decimal
+	}
+	for _, key := range m.ChildrenKeys {
+		child := FilterByLabel(m.ChildrenMap[key], label)
+		if len(child.TypesMap) > 0 {
+			out.AddChild(key, label, child)
+		}
+	}
+	return out
+}
+
+func (d *SchemaDiff) add(kind ChangeKind, path []string, types []DetectedType) {
+	d.Changes = append(d.Changes, Change{Kind: kind, Path: path, Types: types})
+}
+
+func (d *SchemaDiff) diffNode(before, after *Merger) {
+	path := after.Path
+
+	added, removed := diffTypes(before.TypesMap, after.TypesMap)
+	if len(added) > 0 {
+		d.add(ChangeTypesAdded, path, added)
+	}
+	if len(removed) > 0 {
+		d.add(ChangeTypesRemoved, path, removed)
+	}
+
+	_, wasNull := before.TypesMap[TypeNull]
+	_, isNull := after.TypesMap[TypeNull]
+	switch {
+	case !wasNull && isNull:
+		d.add(ChangeBecameNullable, path, nil)
+	case wasNull && !isNull:
+		d.add(ChangeNoLongerNull, path, nil)
+	}
+
+	switch {
+	case !before.IsOptional() && after.IsOptional():
+		d.add(ChangeBecameOptional, path, nil)
+	case before.IsOptional() && !after.IsOptional():
+		d.add(ChangeBecameRequired, path, nil)
+	}
+
+	beforeStrategy, beforeIsArray := arrayStrategy(before)
+	afterStrategy, afterIsArray := arrayStrategy(after)
+	if beforeIsArray && afterIsArray && beforeStrategy != afterStrategy {
+		d.Changes = append(d.Changes, Change{
+			Kind: ChangeArrayStrategy,
+			Path: path,
+			Old:  beforeStrategy.String(),
+			New:  afterStrategy.String(),
+		})
+		// indices and the wildcard can't be matched against each other
+		return
+	}
+
+	for _, key := range before.ChildrenKeys {
+		if _, exists := after.ChildrenMap[key]; !exists {
+			child := before.ChildrenMap[key]
+			d.add(ChangePathRemoved, child.Path, collectTypes(child.TypesMap))
+		}
+	}
+	for _, key := range after.ChildrenKeys {
+		child := after.ChildrenMap[key]
+		beforeChild, exists := before.ChildrenMap[key]
+		if !exists {
+			d.add(ChangePathAdded, child.Path, collectTypes(child.TypesMap))
+			continue
+		}
+		d.diffNode(beforeChild, child)
+	}
+}
+
+// diffTypes compares type sets, nulls are reported as nullability changes
+func diffTypes(before, after map[DetectedType]int) (added, removed []DetectedType) {
+	for _, t := range collectTypes(after) {
+		if _, exists := before[t]; !exists && t != TypeNull {
+			added = append(added, t)
+		}
+	}
+	for _, t := range collectTypes(before) {
+		if _, exists := after[t]; !exists && t != TypeNull {
+			removed = append(removed, t)
+		}
+	}
+	return added, removed
+}
+
+// arrayStrategy tells how array-like children were merged,
+// ok is false if the node has no array-like children
+func arrayStrategy(m *Merger) (strategy ArrayStrategy, ok bool) {
+	_, isArray := m.TypesMap[TypeArray]
+	_, isObjInt := m.TypesMap[TypeObjInt]
+	if !isArray && !isObjInt || len(m.ChildrenMap) == 0 {
+		return ArrayCollapse, false
+	}
+	if _, collapsed := m.ChildrenMap[""]; collapsed {
+		return ArrayCollapse, true
+	}
+	return ArrayKeepIndices, true
+}
@@ -0,0 +1,82 @@
+package jsontype_test
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/4nd3r5on/jsontype"
+)
+
+func TestDiffMergers(t *testing.T) {
+	before := mergeJSON(t, `{
+		"id": 1, "name": "a", "gone": true,
+		"tags": ["x"], "tuple": [1, "a"],
+		"items": [{"a": 1, "b": 2}, {"a": 3}]
+	}`)
+	after := mergeJSON(t, `{
+		"id": 1.5, "name": null, "extra": {"k": "v"},
+		"tags": [1, "x"], "tuple": [1, 2],
+		"items": [{"a": 1, "b": 2}, {"a": 3, "b": 4}]
+	}`)
+
+	var sb strings.Builder
+	diff := jsontype.DiffMergers(before, after)
+	if err := diff.WriteText(&sb); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	t.Log("\n" + sb.String())
+
+	expected := []string{
+		"+ $.extra (object)",
+		"- $.gone (bool)",
+		"~ $.id: types added: float64",
+		"~ $.id: types removed: int32",
+		"~ $.items[].b: became required",
+		"~ $.name: types removed: string",
+		"~ $.name: became nullable",
+		"~ $.tags: array strategy collapse -> keep-indices",
+		"~ $.tuple: array strategy keep-indices -> collapse",
+	}
+	got := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected diff\nGot:\n%s\nExpected:\n%s", strings.Join(got, "\n"), strings.Join(expected, "\n"))
+	}
+
+	if jsontype.DiffMergers(before, before).HasChanges() {
+		t.Errorf("expected no changes when comparing a tree with itself")
+	}
+}
+
+func TestDiffLabels(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	merger := jsontype.NewMerger([]string{})
+	for label, doc := range map[string]string{
+		"v1": `{"id": 1, "email": "admin@email.com"}`,
+		"v2": `{"id": "f3a9c2e7-6b4d-4f81-9a6c-2d8e5b71c0fa", "email": "admin@email.com", "phone": null}`,
+	} {
+		root, err := jsontype.ParseStream(jsontype.NewJSONStream(strings.NewReader(doc)), nil, nil, 0, false, logger)
+		if err != nil {
+			t.Fatalf("parse %s: %v", label, err)
+		}
+		jsontype.MergeFieldInfo(merger, label, root, logger)
+	}
+
+	diff := jsontype.DiffLabels(merger, "v1", "v2")
+	changes := make(map[string]bool)
+	for _, c := range diff.Changes {
+		changes[jsontype.PathToString(c.Path)+" "+string(c.Kind)] = true
+	}
+	for _, want := range []string{
+		"$.id types-added",
+		"$.id types-removed",
+		"$.phone path-added",
+	} {
+		if !changes[want] {
+			t.Errorf("expected %q in the diff, got %v", want, diff.Changes)
+		}
+	}
+	if len(diff.Changes) != 3 {
+		t.Errorf("expected 3 changes, got %v", diff.Changes)
+	}
+}
@@ -0,0 +1,160 @@
+package jsontype
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Direction tells if a body was sent to an endpoint or received from it
+type Direction string
+
+const (
+	DirectionRequest  Direction = "request"
+	DirectionResponse Direction = "response"
+)
+
+// EndpointKey identifies bodies merged together
+type EndpointKey struct {
+	Method string
+	// PathTemplate with parameters, e.g. /users/{id}
+	Path      string
+	Direction Direction
+	// response status code, 0 for requests
+	Status int
+}
+
+func (k EndpointKey) String() string {
+	s := k.Method + " " + k.Path + " " + string(k.Direction)
+	if k.Status != 0 {
+		s += " " + strconv.Itoa(k.Status)
+	}
+	return s
+}
+
+func compareEndpointKeys(a, b EndpointKey) int {
+	return cmp.Or(
+		cmp.Compare(a.Path, b.Path),
+		cmp.Compare(a.Method, b.Method),
+		// requests go before responses
+		cmp.Compare(a.Direction, b.Direction),
+		cmp.Compare(a.Status, b.Status),
+	)
+}
+
+// Endpoint is a merged tree of bodies of an endpoint
+type Endpoint struct {
+	Key EndpointKey
+	// how much bodies were merged
+	Samples int
+	Merger  *Merger
+}
+
+// EndpointSet groups merged bodies by endpoint, direction and status
+type EndpointSet struct {
+	endpoints map[EndpointKey]*Endpoint
+	// Skipped bodies that couldn't be parsed
+	Skipped int
+}
+
+func NewEndpointSet() *EndpointSet {
+	return &EndpointSet{endpoints: make(map[EndpointKey]*Endpoint)}
+}
+
+// Endpoint returns the endpoint for the key, creating it if needed
+func (s *EndpointSet) Endpoint(key EndpointKey) *Endpoint {
+	e, exists := s.endpoints[key]
+	if !exists {
+		e = &Endpoint{Key: key, Merger: NewMerger([]string{})}
+		s.endpoints[key] = e
+	}
+	return e
+}
+
+// Endpoints returns endpoints sorted by path, method, direction and status
+func (s *EndpointSet) Endpoints() []*Endpoint {
+	keys := slices.SortedFunc(maps.Keys(s.endpoints), compareEndpointKeys)
+	endpoints := make([]*Endpoint, len(keys))
+	for i, key := range keys {
+		endpoints[i] = s.endpoints[key]
+	}
+	return endpoints
+}
+
+// Merge adds endpoints of other, see Merger.Merge.
+// other isn't modified
+func (s *EndpointSet) Merge(other *EndpointSet) {
+	for _, e := range other.Endpoints() {
+		endpoint := s.Endpoint(e.Key)
+		endpoint.Samples += e.Samples
+		endpoint.Merger.Merge(e.Merger)
+	}
+	s.Skipped += other.Skipped
+}
+
+// WriteReport prints the merged tree of every endpoint
+func (s *EndpointSet) WriteReport(w io.Writer) {
+	for i, e := range s.Endpoints() {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s (%d samples)\n", e.Key, e.Samples)
+		PrintMergerTree(e.Merger, "  ", w)
+	}
+	if s.Skipped > 0 {
+		fmt.Fprintf(w, "\n%d bodies skipped: invalid JSON\n", s.Skipped)
+	}
+}
+
+// PathTemplate replaces identifiers in the URL path with parameters: /users/42/posts -> /users/{id}/posts.
+// Numbers, UUIDs, long hex strings and long tokens mixing letters and digits are identifiers,
+// parameters after the first one are numbered ({id2}, {id3}, ...)
+func PathTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	params := 0
+	for i, segment := range segments {
+		if !isPathIdentifier(segment) {
+			continue
+		}
+		params++
+		if params == 1 {
+			segments[i] = "{id}"
+		} else {
+			segments[i] = "{id" + strconv.Itoa(params) + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func isPathIdentifier(segment string) bool {
+	if isNumeric(segment) {
+		return true
+	}
+	if _, ok := detectUUID(segment); ok {
+		return true
+	}
+	if len(segment) >= 16 {
+		if _, ok := DetectHex(segment); ok {
+			return true
+		}
+	}
+	if len(segment) < 20 {
+		return false
+	}
+	var letters, digits bool
+	for _, r := range segment {
+		switch {
+		case r >= '0' && r <= '9':
+			digits = true
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			letters = true
+		case r != '-' && r != '_':
+			return false
+		}
+	}
+	return letters && digits
+}
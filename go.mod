@@ -3,3 +3,12 @@ module github.com/4nd3r5on/jsontype
 go 1.25.5
 
 require github.com/4nd3r5on/go-strings-parser v0.0.2 // direct
+
+require (
+	github.com/klauspost/compress v1.20.1
+	github.com/ulikunitz/xz v0.5.17
+)
+
+require gopkg.in/yaml.v3 v3.0.1
+
+require github.com/BurntSushi/toml v1.6.0
@@ -0,0 +1,378 @@
+package jsontype
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GoUnionMode controls how paths holding several unrelated types are generated
+type GoUnionMode int
+
+const (
+	GoUnionAny        GoUnionMode = iota // any
+	GoUnionRawMessage                    // json.RawMessage, decode later by hand
+)
+
+// GoOptions configures GenerateGo
+type GoOptions struct {
+	// PackageName of the generated file (default "main")
+	PackageName string
+	// RootName is the name of the type generated for the root value (default "Root")
+	RootName string
+	// TypeName builds a name of a nested type from the root name and the path segments
+	// of the node relative to the root (wildcards are "", array indices are numeric).
+	// Defaults to DefaultGoTypeName
+	TypeName func(root string, path []string) string
+	// StringMapKeys generates map[string]T instead of map[int]T for object_int
+	StringMapKeys bool
+	// Unions selects the type used for paths with several incompatible types.
+	// Explicit nulls make a field a pointer, missing keys add omitempty
+	Unions GoUnionMode
+}
+
+// DefaultGoTypeName joins the root name with exported forms of the path segments.
+// Wildcards become "Item", array indices become "Item<N>"
+func DefaultGoTypeName(root string, path []string) string {
+	var sb strings.Builder
+	sb.WriteString(root)
+	for _, seg := range path {
+		switch {
+		case seg == "":
+			sb.WriteString("Item")
+		case isNumeric(seg):
+			sb.WriteString("Item" + seg)
+		default:
+			sb.WriteString(GoExportedName(seg))
+		}
+	}
+	return sb.String()
+}
+
+// goInitialisms are upper-cased as a whole, as golint expects
+var goInitialisms = map[string]string{
+	"api": "API", "id": "ID", "ip": "IP", "url": "URL", "uri": "URI", "uuid": "UUID",
+	"http": "HTTP", "https": "HTTPS", "json": "JSON", "html": "HTML", "sql": "SQL",
+	"ttl": "TTL", "cpu": "CPU", "dns": "DNS", "tcp": "TCP", "udp": "UDP", "ui": "UI",
+}
+
+// GoExportedName converts a JSON key into an exported Go identifier
+func GoExportedName(key string) string {
+	words := strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var sb strings.Builder
+	for _, word := range words {
+		// split camelCase words, keeping runs of upper case letters together
+		runes := []rune(word)
+		start := 0
+		for i := 1; i <= len(runes); i++ {
+			if i < len(runes) && !(unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i-1])) {
+				continue
+			}
+			part := string(runes[start:i])
+			if initialism, ok := goInitialisms[strings.ToLower(part)]; ok {
+				sb.WriteString(initialism)
+			} else {
+				r := []rune(part)
+				r[0] = unicode.ToUpper(r[0])
+				sb.WriteString(string(r))
+			}
+			start = i
+		}
+	}
+
+	name := sb.String()
+	if name == "" {
+		return "Field"
+	}
+	if unicode.IsDigit([]rune(name)[0]) {
+		return "F" + name
+	}
+	return name
+}
+
+type goGenerator struct {
+	opts    GoOptions
+	root    []string
+	imports map[string]struct{}
+	names   map[string]int
+	decls   []string
+}
+
+// GenerateGo emits a formatted Go source file with types describing the merged tree
+func GenerateGo(m *Merger, opts GoOptions) ([]byte, error) {
+	if opts.PackageName == "" {
+		opts.PackageName = "main"
+	}
+	if opts.RootName == "" {
+		opts.RootName = "Root"
+	}
+	if opts.TypeName == nil {
+		opts.TypeName = DefaultGoTypeName
+	}
+
+	g := &goGenerator{
+		opts:    opts,
+		root:    m.Path,
+		imports: make(map[string]struct{}),
+		names:   make(map[string]int),
+	}
+
+	rootName := g.reserveName(opts.RootName)
+	rootType, isStruct := g.goType(m, rootName)
+	if !isStruct {
+		g.decls = append([]string{fmt.Sprintf("type %s %s\n", rootName, rootType)}, g.decls...)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by jsontype. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", opts.PackageName)
+	if len(g.imports) > 0 {
+		imports := make([]string, 0, len(g.imports))
+		for imp := range g.imports {
+			imports = append(imports, imp)
+		}
+		slices.Sort(imports)
+		buf.WriteString("import (\n")
+		for _, imp := range imports {
+			fmt.Fprintf(&buf, "\t%q\n", imp)
+		}
+		buf.WriteString(")\n\n")
+	}
+	for _, decl := range g.decls {
+		buf.WriteString(decl)
+		buf.WriteByte('\n')
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return buf.Bytes(), fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return src, nil
+}
+
+// reserveName returns a type name that wasn't used yet
+func (g *goGenerator) reserveName(name string) string {
+	n := g.names[name]
+	g.names[name]++
+	if n == 0 {
+		return name
+	}
+	return name + strconv.Itoa(n+1)
+}
+
+// goType returns a Go type expression for the node.
+// Objects are declared as named structs (named after the path unless name is set),
+// isStruct reports if a struct was declared
+func (g *goGenerator) goType(m *Merger, name string) (typ string, isStruct bool) {
+	kinds := make(map[string]struct{})
+	var hasObj, hasArray, hasObjInt bool
+	types := jsonTypes(m.TypesMap)
+	for t := range types {
+		switch {
+		case t == TypeNull || t == TypeUnknown:
+			continue
+		case t == TypeObj:
+			hasObj = true
+			kinds["object"] = struct{}{}
+		case t == TypeArray:
+			hasArray = true
+			kinds["array"] = struct{}{}
+		case t == TypeObjInt:
+			hasObjInt = true
+			kinds["object_int"] = struct{}{}
+		case t == TypeBool:
+			kinds["bool"] = struct{}{}
+		case IsNumberType(t):
+			kinds["number"] = struct{}{}
+		case IsStringType(t):
+			kinds["string"] = struct{}{}
+		}
+	}
+
+	if len(kinds) != 1 {
+		return g.unionType(), false
+	}
+
+	switch {
+	case hasObj:
+		if name == "" {
+			name = g.nestedName(m)
+		}
+		g.structDecl(m, name)
+		return name, true
+	case hasArray:
+		return "[]" + g.elemType(m), false
+	case hasObjInt:
+		key := "int"
+		if g.opts.StringMapKeys {
+			key = "string"
+		}
+		return fmt.Sprintf("map[%s]%s", key, g.elemType(m)), false
+	}
+
+	if _, isBool := kinds["bool"]; isBool {
+		return "bool", false
+	}
+	if _, isString := kinds["string"]; isString {
+		// time.Time only unmarshals RFC 3339
+		if _, isTime := types[TypeDateTimeRFC3339]; isTime && len(collectStringTypes(m)) == 1 {
+			g.imports["time"] = struct{}{}
+			return "time.Time", false
+		}
+		return "string", false
+	}
+	return g.numberType(m, types), false
+}
+
+// elemType returns the element type of a collapsed array/object_int.
+// Arrays that keep indices hold elements of different types
+func (g *goGenerator) elemType(m *Merger) string {
+	elem, collapsed := m.ChildrenMap[""]
+	if !collapsed {
+		if len(m.ChildrenMap) == 0 {
+			return "any"
+		}
+		return g.unionType()
+	}
+	typ, isStruct := g.goType(elem, "")
+	if isNullable(elem) && isStruct {
+		return "*" + typ
+	}
+	return typ
+}
+
+func (g *goGenerator) unionType() string {
+	if g.opts.Unions == GoUnionRawMessage {
+		g.imports["encoding/json"] = struct{}{}
+		return "json.RawMessage"
+	}
+	return "any"
+}
+
+func (g *goGenerator) nestedName(m *Merger) string {
+	rel := m.Path
+	if len(rel) >= len(g.root) {
+		rel = rel[len(g.root):]
+	}
+	return g.reserveName(g.opts.TypeName(g.opts.RootName, rel))
+}
+
+func (g *goGenerator) structDecl(m *Merger, name string) {
+	// reserve the slot first so parents are declared before children
+	idx := len(g.decls)
+	g.decls = append(g.decls, "")
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "type %s struct {\n", name)
+
+	fieldNames := make(map[string]int)
+	for _, key := range m.ChildrenKeys {
+		if key == "" {
+			continue
+		}
+		child := m.ChildrenMap[key]
+
+		fieldName := GoExportedName(key)
+		fieldNames[fieldName]++
+		if n := fieldNames[fieldName]; n > 1 {
+			fieldName += strconv.Itoa(n)
+		}
+
+		typ, isStruct := g.goType(child, "")
+		// nullable values need a pointer to hold null, optional keys are omitted when empty
+		tag := key
+		if (isNullable(child) || child.IsOptional() && isStruct) && isPointerable(typ) {
+			typ = "*" + typ
+		}
+		if child.IsOptional() {
+			tag += ",omitempty"
+		}
+
+		fmt.Fprintf(&sb, "\t%s %s `json:%q`", fieldName, typ, tag)
+		if comment := extendedTypesComment(child); comment != "" {
+			sb.WriteString(" // " + comment)
+		}
+		sb.WriteByte('\n')
+	}
+	sb.WriteString("}\n")
+	g.decls[idx] = sb.String()
+}
+
+// isNullable reports if the node was explicitly null at least once
+func isNullable(m *Merger) bool {
+	_, hasNull := m.TypesMap[TypeNull]
+	return hasNull
+}
+
+// isPointerable reports if a pointer adds information to the type,
+// slices, maps and interfaces are nil-able on their own
+func isPointerable(typ string) bool {
+	return !strings.HasPrefix(typ, "[]") && !strings.HasPrefix(typ, "map[") &&
+		!strings.HasPrefix(typ, "*") && typ != "any" && typ != "json.RawMessage"
+}
+
+// float64MaxDigits is the number of decimal digits float64 always keeps
+const float64MaxDigits = 15
+
+// numberType picks the narrowest Go type holding every number met at the path.
+// Decimals float64 would round and integers beyond 64 bits are kept as written
+func (g *goGenerator) numberType(m *Merger, types map[DetectedType]int) string {
+	_, hasFloat := types[TypeFloat64]
+	_, hasBigInt := types[TypeBigInt]
+	_, hasUint64 := types[TypeUint64]
+	if hasFloat && (hasBigInt || m.Decimal.Precision() > float64MaxDigits) {
+		g.imports["encoding/json"] = struct{}{}
+		return "json.Number"
+	}
+	if hasFloat {
+		return "float64"
+	}
+
+	signed := false
+	for t := range types {
+		if IsIntegerType(t) && t != TypeInt32 && t != TypeUint64 && t != TypeBigInt {
+			signed = true
+		}
+	}
+	_, hasInt32 := types[TypeInt32]
+	switch {
+	// uint64 values may share the path with negative ones
+	case hasBigInt, hasUint64 && (signed || hasInt32):
+		g.imports["math/big"] = struct{}{}
+		return "*big.Int"
+	case hasUint64:
+		return "uint64"
+	case signed:
+		return "int64"
+	}
+	return "int32"
+}
+
+func collectStringTypes(m *Merger) []DetectedType {
+	var out []DetectedType
+	for _, t := range collectTypes(jsonTypes(m.TypesMap)) {
+		if IsStringType(t) {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// extendedTypesComment lists extended string types so the information isn't lost
+func extendedTypesComment(m *Merger) string {
+	var extended []string
+	for _, t := range collectTypes(m.TypesMap) {
+		// types of binary formats are named as is
+		if IsStringType(JSONEquivalentType(t)) && t != TypeString {
+			extended = append(extended, string(t))
+		}
+	}
+	return strings.Join(extended, " | ")
+}
@@ -0,0 +1,95 @@
+package jsontype_test
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/4nd3r5on/jsontype"
+)
+
+func TestGenerateGo(t *testing.T) {
+	merger := mergeJSON(t, `{
+		"user_id": 1,
+		"email": "admin@email.com",
+		"tags": ["a", "b"],
+		"scores": {"1": 1.5, "2": 3.5},
+		"items": [{"a": 1}, {"a": 2, "b": "text"}, {"a": 3, "b": null}]
+	}`)
+
+	src, err := jsontype.GenerateGo(merger, jsontype.GoOptions{
+		PackageName: "api",
+		RootName:    "User",
+	})
+	if err != nil {
+		t.Fatalf("generate: %v\n%s", err, src)
+	}
+	t.Log("\n" + string(src))
+
+	if _, err := parser.ParseFile(token.NewFileSet(), "gen.go", src, 0); err != nil {
+		t.Fatalf("generated code doesn't parse: %v", err)
+	}
+
+	for _, want := range []string{
+		"package api",
+		"type User struct {",
+		"UserID int32 `json:\"user_id\"`",
+		"Email  string `json:\"email\"` // string-email",
+		"Tags   []string `json:\"tags\"`",
+		"Scores map[int]float64 `json:\"scores\"`",
+		"Items  []UserItemsItem `json:\"items\"`",
+		"type UserItemsItem struct {",
+		"B *string `json:\"b,omitempty\"`",
+	} {
+		if !strings.Contains(normalizeSpaces(string(src)), normalizeSpaces(want)) {
+			t.Errorf("generated code doesn't contain %q", want)
+		}
+	}
+}
+
+func TestGenerateGo_Numbers(t *testing.T) {
+	merger := mergeJSON(t,
+		`{"price": 1.25, "precise": 0.1234567890123456789, "id": 18446744073709551615, "huge": 123456789012345678901234567890, "mixed": 18446744073709551615, "count": 3000000000}`,
+		`{"price": 10.5, "precise": 1.5, "id": 18446744073709551614, "huge": 1, "mixed": -1, "count": 1}`,
+	)
+	src, err := jsontype.GenerateGo(merger, jsontype.GoOptions{PackageName: "api", RootName: "Row"})
+	if err != nil {
+		t.Fatalf("generate: %v\n%s", err, src)
+	}
+
+	for _, want := range []string{
+		`"encoding/json"`,
+		`"math/big"`,
+		"Price float64 `json:\"price\"`",
+		"Precise json.Number `json:\"precise\"`",
+		"ID uint64 `json:\"id\"`",
+		"Huge *big.Int `json:\"huge\"`",
+		"Mixed *big.Int `json:\"mixed\"`",
+		"Count int64 `json:\"count\"`",
+	} {
+		if !strings.Contains(normalizeSpaces(string(src)), normalizeSpaces(want)) {
+			t.Errorf("generated code doesn't contain %q\n%s", want, src)
+		}
+	}
+}
+
+func TestGoExportedName(t *testing.T) {
+	tests := map[string]string{
+		"user_id":    "UserID",
+		"createdAt":  "CreatedAt",
+		"api-url":    "APIURL",
+		"HTTPServer": "HTTPServer",
+		"1st":        "F1st",
+		"$":          "Field",
+	}
+	for in, want := range tests {
+		if got := jsontype.GoExportedName(in); got != want {
+			t.Errorf("GoExportedName(%q) = %q, expected %q", in, got, want)
+		}
+	}
+}
+
+func normalizeSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,176 @@
+package jsontype
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+// harContent is a request or response body of a HAR entry
+type harContent struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+	Encoding string `json:"encoding"`
+}
+
+// harEntry is the part of a HAR entry used to infer schemas
+type harEntry struct {
+	Request struct {
+		Method   string      `json:"method"`
+		URL      string      `json:"url"`
+		PostData *harContent `json:"postData"`
+	} `json:"request"`
+	Response struct {
+		Status  int        `json:"status"`
+		Content harContent `json:"content"`
+	} `json:"response"`
+}
+
+// MergeHAR merges JSON request and response bodies of the HAR archive into the set.
+// Bodies are grouped by method, PathTemplate of the URL, direction and status,
+// and merged under the label. Bodies that aren't valid JSON are counted in EndpointSet.Skipped
+func MergeHAR(set *EndpointSet, label string, r io.Reader, opts ...ParseOption) error {
+	return MergeHARWithOptions(set, label, r, NewParseOptions(opts...))
+}
+
+// MergeHARWithOptions is MergeHAR taking options as a struct
+func MergeHARWithOptions(set *EndpointSet, label string, r io.Reader, o ParseOptions) error {
+	logger := o.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return decodeHAREntries(json.NewDecoder(r), func(entry *harEntry) error {
+		u, err := url.Parse(entry.Request.URL)
+		if err != nil {
+			logger.Warn("skipping HAR entry with invalid URL", "label", label, "url", entry.Request.URL)
+			return nil
+		}
+		key := EndpointKey{
+			Method: strings.ToUpper(entry.Request.Method),
+			Path:   PathTemplate(cmp.Or(u.Path, "/")),
+		}
+
+		if entry.Request.PostData != nil {
+			key.Direction = DirectionRequest
+			if err := mergeHARBody(set, key, label, *entry.Request.PostData, o, logger); err != nil {
+				return err
+			}
+		}
+		key.Direction = DirectionResponse
+		key.Status = entry.Response.Status
+		return mergeHARBody(set, key, label, entry.Response.Content, o, logger)
+	})
+}
+
+// decodeHAREntries calls fn for every entry of log.entries without decoding the whole archive
+func decodeHAREntries(dec *json.Decoder, fn func(entry *harEntry) error) error {
+	// walkObject calls fn for the value of every key of the next object
+	walkObject := func(fn func(key string) error) error {
+		token, err := dec.Token()
+		if err != nil {
+			return fmt.Errorf("invalid HAR: %w", err)
+		}
+		if !IsDelim(token, '{') {
+			return fmt.Errorf("invalid HAR: expected an object, got %v", token)
+		}
+		for dec.More() {
+			token, err := dec.Token()
+			if err != nil {
+				return fmt.Errorf("invalid HAR: %w", err)
+			}
+			if err := fn(token.(string)); err != nil {
+				return err
+			}
+		}
+		_, err = dec.Token() // '}'
+		return err
+	}
+	skip := func() error {
+		var raw json.RawMessage
+		return dec.Decode(&raw)
+	}
+
+	return walkObject(func(key string) error {
+		if key != "log" {
+			return skip()
+		}
+		return walkObject(func(key string) error {
+			if key != "entries" {
+				return skip()
+			}
+			token, err := dec.Token()
+			if err != nil {
+				return fmt.Errorf("invalid HAR: %w", err)
+			}
+			if !IsDelim(token, '[') {
+				return fmt.Errorf("invalid HAR: expected entries array, got %v", token)
+			}
+			for dec.More() {
+				var entry harEntry
+				if err := dec.Decode(&entry); err != nil {
+					return fmt.Errorf("invalid HAR entry: %w", err)
+				}
+				if err := fn(&entry); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token() // ']'
+			return err
+		})
+	})
+}
+
+// mergeHARBody merges the body into the endpoint if it has a JSON content type
+func mergeHARBody(set *EndpointSet, key EndpointKey, label string, content harContent, o ParseOptions, logger *slog.Logger) error {
+	isJSON, isNDJSON := jsonMediaType(content.MimeType)
+	if !isJSON || content.Text == "" {
+		return nil
+	}
+
+	var body io.Reader = strings.NewReader(content.Text)
+	if content.Encoding == "base64" {
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	}
+	o.NDJSON = o.NDJSON || isNDJSON
+
+	result, err := MergeStreamWithOptions(nil, label, NewJSONStream(body), o)
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	if err != nil {
+		logger.Warn("skipping invalid JSON body", "label", label, "endpoint", key.String(), "error", err)
+		set.Skipped++
+		return nil
+	}
+
+	endpoint := set.Endpoint(key)
+	endpoint.Samples++
+	endpoint.Merger.Merge(result)
+	return nil
+}
+
+// jsonMediaType reports if the content type is JSON (application/json, application/*+json)
+// or newline-delimited JSON
+func jsonMediaType(contentType string) (isJSON, isNDJSON bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, _, _ = strings.Cut(strings.ToLower(contentType), ";")
+		mediaType = strings.TrimSpace(mediaType)
+	}
+	switch mediaType {
+	case "application/json", "text/json":
+		return true, false
+	case "application/x-ndjson", "application/ndjson", "application/jsonl", "application/x-jsonlines":
+		return true, true
+	}
+	return strings.HasSuffix(mediaType, "+json"), false
+}
@@ -0,0 +1,79 @@
+package jsontype_test
+
+import (
+	"encoding/base64"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/4nd3r5on/jsontype"
+)
+
+func TestPathTemplate(t *testing.T) {
+	tests := map[string]string{
+		"/users/42":         "/users/{id}",
+		"/users/42/posts/7": "/users/{id}/posts/{id2}",
+		"/orders/3fa85f64-5717-4562-b3fc-2c963f66afa6": "/orders/{id}",
+		"/objects/507f1f77bcf86cd799439011":            "/objects/{id}",
+		"/sessions/sess_9aB3kLm2QpR7tUvW1xYz":          "/sessions/{id}",
+		"/api/v1/users":                                "/api/v1/users",
+		"/":                                            "/",
+	}
+	for path, expected := range tests {
+		if got := jsontype.PathTemplate(path); got != expected {
+			t.Errorf("PathTemplate(%q) = %q, expected %q", path, got, expected)
+		}
+	}
+}
+
+func TestMergeHAR(t *testing.T) {
+	encoded := base64.StdEncoding.EncodeToString([]byte(`{"id": 2, "name": "bob", "admin": true}`))
+	har := `{"log": {"version": "1.2", "creator": {"name": "test"}, "entries": [
+		{"request": {"method": "GET", "url": "https://api.example.com/users/1?fields=all"},
+		 "response": {"status": 200, "content": {"mimeType": "application/json; charset=utf-8", "text": "{\"id\": 1, \"name\": \"alice\"}"}}},
+		{"request": {"method": "GET", "url": "https://api.example.com/users/2"},
+		 "response": {"status": 200, "content": {"mimeType": "application/json", "text": "` + encoded + `", "encoding": "base64"}}},
+		{"request": {"method": "GET", "url": "https://api.example.com/users/3"},
+		 "response": {"status": 404, "content": {"mimeType": "application/problem+json", "text": "{\"error\": \"not found\"}"}}},
+		{"request": {"method": "post", "url": "https://api.example.com/users", "postData": {"mimeType": "application/json", "text": "{\"name\": \"carol\"}"}},
+		 "response": {"status": 201, "content": {"mimeType": "application/json", "text": "{\"id\": 4"}}},
+		{"request": {"method": "GET", "url": "https://api.example.com/"},
+		 "response": {"status": 200, "content": {"mimeType": "text/html", "text": "<html></html>"}}}
+	]}}`
+
+	set := jsontype.NewEndpointSet()
+	err := jsontype.MergeHAR(set, "capture.har", strings.NewReader(har),
+		jsontype.WithLogger(slog.New(slog.DiscardHandler)))
+	if err != nil {
+		t.Fatalf("merge HAR: %v", err)
+	}
+
+	var keys []string
+	for _, e := range set.Endpoints() {
+		keys = append(keys, e.Key.String())
+	}
+	expectKeys := "POST /users request, GET /users/{id} response 200, GET /users/{id} response 404"
+	if got := strings.Join(keys, ", "); got != expectKeys {
+		t.Fatalf("unexpected endpoints\nGot:      %s\nExpected: %s", got, expectKeys)
+	}
+	if set.Skipped != 1 {
+		t.Errorf("expected the truncated body to be skipped, got %d", set.Skipped)
+	}
+
+	users := set.Endpoint(jsontype.EndpointKey{Method: "GET", Path: "/users/{id}", Direction: jsontype.DirectionResponse, Status: 200})
+	if users.Samples != 2 {
+		t.Errorf("expected 2 samples, got %d", users.Samples)
+	}
+	admin := users.Merger.ChildrenMap["admin"]
+	if admin == nil || !admin.IsOptional() {
+		t.Errorf("field 'admin' should be optional")
+	}
+
+	var report strings.Builder
+	set.WriteReport(&report)
+	for _, line := range []string{"GET /users/{id} response 200 (2 samples)", "  $.name => string", "1 bodies skipped"} {
+		if !strings.Contains(report.String(), line) {
+			t.Errorf("report doesn't contain %q:\n%s", line, report.String())
+		}
+	}
+}
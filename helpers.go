@@ -2,7 +2,6 @@ package jsontype
 
 import (
 	"encoding/json"
-	"fmt"
 	"strings"
 )
 
@@ -16,18 +15,6 @@ func detectNumberType(f float64) DetectedType {
 	return TypeFloat64
 }
 
-func detectNumberTypeFromString(s string) DetectedType {
-	// Try to parse as int first
-	var i int64
-	if _, err := fmt.Sscanf(s, "%d", &i); err == nil {
-		if i >= -2147483648 && i <= 2147483647 {
-			return TypeInt32
-		}
-		return TypeInt64
-	}
-	return TypeFloat64
-}
-
 func isIntegerKey(key string) bool {
 	for _, c := range key {
 		if c < '0' || c > '9' {
@@ -154,6 +141,11 @@ func pathMatches(current, target []string) bool {
 	return true
 }
 
+// pathHasPrefix reports if path is prefix itself or lies below it
+func pathHasPrefix(path, prefix []string) bool {
+	return len(path) >= len(prefix) && pathMatches(path, prefix)
+}
+
 func isNumeric(s string) bool {
 	if s == "" {
 		return false
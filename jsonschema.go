@@ -0,0 +1,291 @@
+package jsontype
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"slices"
+	"strconv"
+)
+
+// JSONSchemaDialect is the meta-schema written into the root of generated schemas
+const JSONSchemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema is the subset of the JSON Schema (draft 2020-12) vocabulary
+// needed to describe a structure inferred by the Merger
+type JSONSchema struct {
+	Schema string `json:"$schema,omitempty"`
+	Ref    string `json:"$ref,omitempty"`
+
+	Type            SchemaTypes `json:"type,omitempty"`
+	Format          string      `json:"format,omitempty"`
+	ContentEncoding string      `json:"contentEncoding,omitempty"`
+
+	// Objects
+	Properties           SchemaProperties `json:"properties,omitempty"`
+	Required             []string         `json:"required,omitempty"`
+	PropertyNames        *JSONSchema      `json:"propertyNames,omitempty"`
+	AdditionalProperties *JSONSchema      `json:"additionalProperties,omitempty"`
+	Pattern              string           `json:"pattern,omitempty"`
+
+	// Arrays
+	PrefixItems []*JSONSchema `json:"prefixItems,omitempty"`
+	Items       *JSONSchema   `json:"items,omitempty"`
+
+	AnyOf []*JSONSchema `json:"anyOf,omitempty"`
+}
+
+// SchemaTypes is the value of the "type" keyword.
+// A single type is encoded as a string, several types as an array
+type SchemaTypes []string
+
+func (t SchemaTypes) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+// SchemaProperty is a single named entry of the "properties" keyword
+type SchemaProperty struct {
+	Name   string
+	Schema *JSONSchema
+}
+
+// SchemaProperties keeps properties in the order keys were met in the input
+type SchemaProperties []SchemaProperty
+
+func (p SchemaProperties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, prop := range p {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(prop.Name)
+		if err != nil {
+			return nil, err
+		}
+		val, err := json.Marshal(prop.Schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// stringFormats maps extended string types to the "format" keyword
+var stringFormats = map[DetectedType]string{
+	TypeUUID:   "uuid",
+	TypeEmail:  "email",
+	TypeIPv4:   "ipv4",
+	TypeIPv6:   "ipv6",
+	TypeLink:   "uri",
+	TypeDomain: "hostname",
+
+	TypeDateTimeRFC3339: "date-time",
+	TypeDate:            "date",
+	TypeTime:            "time",
+	TypeDuration:        "duration",
+}
+
+// stringEncodings maps extended string types to the "contentEncoding" keyword
+var stringEncodings = map[DetectedType]string{
+	TypeHEX:       "base16",
+	TypeBase64Std: "base64",
+}
+
+// MergerToJSONSchema converts a merged tree into a JSON Schema document
+func MergerToJSONSchema(m *Merger) *JSONSchema {
+	schema := mergerToSchema(m)
+	schema.Schema = JSONSchemaDialect
+	return schema
+}
+
+// WriteJSONSchema writes an indented JSON Schema document for the merged tree
+func WriteJSONSchema(m *Merger, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(MergerToJSONSchema(m))
+}
+
+func mergerToSchema(m *Merger) *JSONSchema {
+	if m == nil {
+		return &JSONSchema{}
+	}
+
+	var (
+		nullable bool
+		strs     []DetectedType
+		ints     bool
+		floats   bool
+		branches []*JSONSchema
+	)
+	for _, t := range collectTypes(jsonTypes(m.TypesMap)) {
+		switch {
+		case t == TypeUnknown:
+			// anything goes
+			return &JSONSchema{}
+		case t == TypeNull:
+			nullable = true
+		case t == TypeBool:
+			branches = append(branches, &JSONSchema{Type: SchemaTypes{"boolean"}})
+		case IsIntegerType(t):
+			ints = true
+		case t == TypeFloat64:
+			floats = true
+		case IsStringType(t):
+			strs = append(strs, t)
+		case t == TypeObj:
+			branches = append(branches, objectSchema(m))
+		case t == TypeObjInt:
+			branches = append(branches, objectIntSchema(m))
+		case t == TypeArray:
+			branches = append(branches, arraySchema(m))
+		}
+	}
+
+	// integers are a subset of numbers
+	switch {
+	case floats:
+		branches = append(branches, &JSONSchema{Type: SchemaTypes{"number"}})
+	case ints:
+		branches = append(branches, &JSONSchema{Type: SchemaTypes{"integer"}})
+	}
+	if len(strs) > 0 {
+		branches = append(branches, stringSchema(strs))
+	}
+
+	return unionSchema(branches, nullable)
+}
+
+// stringSchema keeps a format only if every string met at the path agrees on it
+func stringSchema(types []DetectedType) *JSONSchema {
+	s := &JSONSchema{Type: SchemaTypes{"string"}}
+	if len(types) != 1 {
+		return s
+	}
+	s.Format = stringFormats[types[0]]
+	s.ContentEncoding = stringEncodings[types[0]]
+	return s
+}
+
+func objectSchema(m *Merger) *JSONSchema {
+	s := &JSONSchema{Type: SchemaTypes{"object"}}
+	for _, key := range m.ChildrenKeys {
+		if key == "" {
+			continue
+		}
+		child := m.ChildrenMap[key]
+		s.Properties = append(s.Properties, SchemaProperty{
+			Name:   key,
+			Schema: mergerToSchema(child),
+		})
+		if !child.IsOptional() {
+			s.Required = append(s.Required, key)
+		}
+	}
+	return s
+}
+
+func objectIntSchema(m *Merger) *JSONSchema {
+	s := &JSONSchema{
+		Type:          SchemaTypes{"object"},
+		PropertyNames: &JSONSchema{Pattern: "^[0-9]+$"},
+	}
+	if elem, collapsed := m.ChildrenMap[""]; collapsed {
+		s.AdditionalProperties = mergerToSchema(elem)
+		return s
+	}
+	for _, key := range sortedIndexKeys(m) {
+		s.Properties = append(s.Properties, SchemaProperty{
+			Name:   key,
+			Schema: mergerToSchema(m.ChildrenMap[key]),
+		})
+	}
+	return s
+}
+
+func arraySchema(m *Merger) *JSONSchema {
+	s := &JSONSchema{Type: SchemaTypes{"array"}}
+	if elem, collapsed := m.ChildrenMap[""]; collapsed {
+		s.Items = mergerToSchema(elem)
+		return s
+	}
+	for _, key := range sortedIndexKeys(m) {
+		s.PrefixItems = append(s.PrefixItems, mergerToSchema(m.ChildrenMap[key]))
+	}
+	return s
+}
+
+// unionSchema combines branches into a single schema.
+// Branches that only declare a type are folded into one "type" list,
+// anything more specific ends up in "anyOf"
+func unionSchema(branches []*JSONSchema, nullable bool) *JSONSchema {
+	if len(branches) == 0 {
+		if nullable {
+			return &JSONSchema{Type: SchemaTypes{"null"}}
+		}
+		return &JSONSchema{}
+	}
+	if len(branches) == 1 {
+		s := branches[0]
+		if nullable {
+			s.Type = append(s.Type, "null")
+		}
+		return s
+	}
+
+	simple := true
+	for _, b := range branches {
+		if !isTypeOnlySchema(b) {
+			simple = false
+			break
+		}
+	}
+	if simple {
+		s := &JSONSchema{}
+		for _, b := range branches {
+			s.Type = append(s.Type, b.Type...)
+		}
+		if nullable {
+			s.Type = append(s.Type, "null")
+		}
+		return s
+	}
+
+	if nullable {
+		branches = append(branches, &JSONSchema{Type: SchemaTypes{"null"}})
+	}
+	return &JSONSchema{AnyOf: branches}
+}
+
+func isTypeOnlySchema(s *JSONSchema) bool {
+	return len(s.Type) > 0 &&
+		s.Format == "" && s.ContentEncoding == "" &&
+		s.Properties == nil && s.Required == nil && s.PropertyNames == nil && s.AdditionalProperties == nil &&
+		s.PrefixItems == nil && s.Items == nil && s.AnyOf == nil
+}
+
+// sortedIndexKeys returns children keys of an array-like node in numeric order
+func sortedIndexKeys(m *Merger) []string {
+	keys := make([]string, 0, len(m.ChildrenKeys))
+	for _, key := range m.ChildrenKeys {
+		if key != "" {
+			keys = append(keys, key)
+		}
+	}
+	slices.SortStableFunc(keys, func(a, b string) int {
+		ai, aErr := strconv.Atoi(a)
+		bi, bErr := strconv.Atoi(b)
+		if aErr != nil || bErr != nil {
+			return 0
+		}
+		return ai - bi
+	})
+	return keys
+}
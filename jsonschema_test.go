@@ -0,0 +1,71 @@
+package jsontype_test
+
+import (
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/4nd3r5on/jsontype"
+)
+
+func mergeJSON(t *testing.T, docs ...string) *jsontype.Merger {
+	t.Helper()
+	logger := slog.New(slog.DiscardHandler)
+	merger := jsontype.NewMerger([]string{})
+	for i, doc := range docs {
+		root, err := jsontype.ParseStream(jsontype.NewJSONStream(strings.NewReader(doc)), nil, nil, 0, false, logger)
+		if err != nil {
+			t.Fatalf("parse document %d: %v", i, err)
+		}
+		jsontype.MergeFieldInfo(merger, "test", root, logger)
+	}
+	return merger
+}
+
+func TestMergerToJSONSchema(t *testing.T) {
+	merger := mergeJSON(t, `{
+		"id": "f3a9c2e7-6b4d-4f81-9a6c-2d8e5b71c0fa",
+		"tags": ["a", "b"],
+		"tuple": [1, "x"],
+		"scores": {"1": 1.5, "2": 3.5},
+		"items": [{"a": 1}, {"a": 2, "b": "text"}, {"a": 3, "b": null}]
+	}`)
+
+	schema := jsontype.MergerToJSONSchema(merger)
+	got, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("marshal schema: %v", err)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(got, &doc); err != nil {
+		t.Fatalf("unmarshal schema: %v", err)
+	}
+	if doc["$schema"] != jsontype.JSONSchemaDialect {
+		t.Errorf("expected $schema %q, got %v", jsontype.JSONSchemaDialect, doc["$schema"])
+	}
+
+	props := doc["properties"].(map[string]any)
+	expect := map[string]string{
+		"id":     `{"type":"string","format":"uuid"}`,
+		"tags":   `{"type":"array","items":{"type":"string"}}`,
+		"tuple":  `{"type":"array","prefixItems":[{"type":"integer"},{"type":"string"}]}`,
+		"scores": `{"type":"object","propertyNames":{"pattern":"^[0-9]+$"},"additionalProperties":{"type":"number"}}`,
+		"items":  `{"type":"array","items":{"type":"object","properties":{"a":{"type":"integer"},"b":{"type":["string","null"]}},"required":["a"]}}`,
+	}
+	for name, want := range expect {
+		prop, exists := props[name]
+		if !exists {
+			t.Errorf("property %q is missing", name)
+			continue
+		}
+		gotProp, _ := json.Marshal(prop)
+		var wantProp any
+		_ = json.Unmarshal([]byte(want), &wantProp)
+		wantJSON, _ := json.Marshal(wantProp)
+		if string(gotProp) != string(wantJSON) {
+			t.Errorf("property %q:\nGot:      %s\nExpected: %s", name, gotProp, wantJSON)
+		}
+	}
+}
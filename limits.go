@@ -0,0 +1,96 @@
+package jsontype
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ParseLimits bound resources spent on a single input, zero means unlimited
+type ParseLimits struct {
+	// MaxBytes read from the underlying reader, the stream must implement ReadLimiter
+	MaxBytes int64
+	// MaxTokens read from the stream, not counting tokens of skipped values
+	MaxTokens int64
+	// MaxNesting of objects and arrays (the root container is at nesting 1)
+	MaxNesting int
+	// MaxObjectKeys in a single object
+	MaxObjectKeys int
+	// MaxArrayElements analyzed per array, the rest are skipped without an error
+	MaxArrayElements int
+	// MaxStringLength of string values and object keys in bytes
+	MaxStringLength int
+}
+
+// LimitKind names the exceeded limit
+type LimitKind string
+
+const (
+	LimitBytes        LimitKind = "bytes"
+	LimitTokens       LimitKind = "tokens"
+	LimitNesting      LimitKind = "nesting"
+	LimitObjectKeys   LimitKind = "object keys"
+	LimitStringLength LimitKind = "string length"
+)
+
+// ErrLimitExceeded matches every LimitError with errors.Is
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+// LimitError is returned when the input exceeds one of ParseLimits
+type LimitError struct {
+	Limit LimitKind
+	Max   int64
+	// Path where the limit was exceeded, nil if unknown
+	Path []string
+}
+
+func (e *LimitError) Error() string {
+	if e.Path == nil {
+		return fmt.Sprintf("%s limit of %d exceeded", e.Limit, e.Max)
+	}
+	return fmt.Sprintf("%s limit of %d exceeded at %s", e.Limit, e.Max, PathToString(e.Path))
+}
+
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded
+}
+
+// ReadLimiter is implemented by streams able to bound the number of bytes
+// read from the underlying reader
+type ReadLimiter interface {
+	SetReadLimit(n int64)
+}
+
+// limitedReader fails with a LimitError once more than limit bytes are read,
+// limit <= 0 means unlimited
+type limitedReader struct {
+	r     io.Reader
+	read  int64
+	limit int64
+	// tracks lines of text formats, may be nil
+	lines *lineTracker
+}
+
+func (l *limitedReader) Read(b []byte) (int, error) {
+	if l.limit > 0 {
+		remaining := l.limit - l.read
+		if remaining <= 0 {
+			// input of exactly limit bytes is fine
+			var probe [1]byte
+			n, err := l.r.Read(probe[:])
+			if n == 0 && err != nil {
+				return 0, err
+			}
+			return 0, &LimitError{Limit: LimitBytes, Max: l.limit}
+		}
+		if int64(len(b)) > remaining {
+			b = b[:remaining]
+		}
+	}
+	n, err := l.r.Read(b)
+	l.read += int64(n)
+	if l.lines != nil {
+		l.lines.write(b[:n])
+	}
+	return n, err
+}
@@ -3,6 +3,7 @@ package jsontype
 import (
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
 )
 
@@ -11,10 +12,20 @@ import (
 type Merger struct {
 	// full path of this node (immutable after creation)
 	Path []string
-	// map( label : set(type) )
-	LabeledTypesMap map[string]map[DetectedType]struct{}
+	// map( label : map(type : count) )
+	LabeledTypesMap map[string]map[DetectedType]int
 	// how much times each type was met
-	TypesMap map[DetectedType]struct{}
+	TypesMap map[DetectedType]int
+	// widest digits of decimal numbers at this path
+	Decimal DecimalInfo
+	// map( label : map(integer type : count) ) of values detected as unix timestamps,
+	// ResolveEpochTypes demotes them back to these types
+	epochIntTypes map[string]map[DetectedType]int
+	// how much values were observed at this path
+	Occurrences int
+	// for object fields: how much parent objects could have contained the key,
+	// zero for anything that isn't an object field
+	ParentOccurrences int
 	// children keyed by the immediate child key (for arrays use "0", "1", etc as keys).
 	// if type isn't mixed (for some labels) -- all data is written under the same key ""
 	ChildrenMap map[string]*Merger
@@ -25,23 +36,47 @@ type Merger struct {
 func NewMerger(path []string) *Merger {
 	return &Merger{
 		Path:            path,
-		LabeledTypesMap: make(map[string]map[DetectedType]struct{}),
-		TypesMap:        make(map[DetectedType]struct{}),
+		LabeledTypesMap: make(map[string]map[DetectedType]int),
+		TypesMap:        make(map[DetectedType]int),
 		ChildrenMap:     make(map[string]*Merger),
 		ChildrenKeys:    make([]string, 0),
 	}
 }
 
+// AddTypes counts a single observation of each of the types
 func (m *Merger) AddTypes(label string, types ...DetectedType) {
-	if m.LabeledTypesMap[label] == nil {
-		m.LabeledTypesMap[label] = make(map[DetectedType]struct{})
-	}
 	for _, t := range types {
-		if _, exist := m.LabeledTypesMap[label][t]; !exist {
-			m.LabeledTypesMap[label][t] = struct{}{}
-			m.TypesMap[t] = struct{}{}
-		}
+		m.AddTypeCount(label, t, 1)
+	}
+}
+
+// addField counts the type of a parsed value and the digits of decimal numbers
+func (m *Merger) addField(label string, f *FieldInfo) {
+	m.AddTypes(label, f.Type)
+	m.Decimal = m.Decimal.Widen(f.Decimal)
+	if f.IntType != "" {
+		m.addEpochIntType(label, f.IntType, 1)
+	}
+}
+
+// addEpochIntType counts n timestamps read as the integer type
+func (m *Merger) addEpochIntType(label string, t DetectedType, n int) {
+	if m.epochIntTypes == nil {
+		m.epochIntTypes = make(map[string]map[DetectedType]int)
 	}
+	if m.epochIntTypes[label] == nil {
+		m.epochIntTypes[label] = make(map[DetectedType]int)
+	}
+	m.epochIntTypes[label][t] += n
+}
+
+// AddTypeCount counts n observations of the type
+func (m *Merger) AddTypeCount(label string, t DetectedType, n int) {
+	if m.LabeledTypesMap[label] == nil {
+		m.LabeledTypesMap[label] = make(map[DetectedType]int)
+	}
+	m.LabeledTypesMap[label][t] += n
+	m.TypesMap[t] += n
 }
 
 func (m *Merger) AddChild(key string, label string, child *Merger) *Merger {
@@ -51,14 +86,18 @@ func (m *Merger) AddChild(key string, label string, child *Merger) *Merger {
 		m.ChildrenKeys = append(m.ChildrenKeys, key)
 		return child
 	}
-	// copy types
-	typesBuf := make([]DetectedType, len(child.TypesMap))
-	i := 0
-	for t := range child.TypesMap {
-		typesBuf[i] = t
-		i++
+	// copy types and counters
+	for t, n := range child.TypesMap {
+		existingChild.AddTypeCount(label, t, n)
 	}
-	existingChild.AddTypes(label, typesBuf...)
+	for _, intTypes := range child.epochIntTypes {
+		for t, n := range intTypes {
+			existingChild.addEpochIntType(label, t, n)
+		}
+	}
+	existingChild.Decimal = existingChild.Decimal.Widen(child.Decimal)
+	existingChild.Occurrences += child.Occurrences
+	existingChild.ParentOccurrences += child.ParentOccurrences
 	// copy children
 	for _, newChildKey := range child.ChildrenKeys {
 		existingChild.AddChild(newChildKey, label, child.ChildrenMap[newChildKey])
@@ -66,6 +105,70 @@ func (m *Merger) AddChild(key string, label string, child *Merger) *Merger {
 	return existingChild
 }
 
+// Merge adds everything aggregated by other, keeping its labels.
+// Object fields missing from one of the trees become optional.
+// other isn't modified. Merge is associative: merging a with b and then c
+// gives the same tree as merging a with the result of merging b and c,
+// including the order of ChildrenKeys (new keys are appended in the order of other)
+func (m *Merger) Merge(other *Merger) {
+	// objects of one tree are parents of fields found only in the other one
+	objects, otherObjects := m.TypesMap[TypeObj], other.TypesMap[TypeObj]
+
+	for label, types := range other.LabeledTypesMap {
+		for t, n := range types {
+			m.AddTypeCount(label, t, n)
+		}
+	}
+	for label, intTypes := range other.epochIntTypes {
+		for t, n := range intTypes {
+			m.addEpochIntType(label, t, n)
+		}
+	}
+	m.Decimal = m.Decimal.Widen(other.Decimal)
+	m.Occurrences += other.Occurrences
+	m.ParentOccurrences += other.ParentOccurrences
+
+	for _, key := range m.ChildrenKeys {
+		child := m.ChildrenMap[key]
+		if _, exists := other.ChildrenMap[key]; !exists && child.ParentOccurrences > 0 {
+			child.ParentOccurrences += otherObjects
+		}
+	}
+	for _, key := range other.ChildrenKeys {
+		otherChild := other.ChildrenMap[key]
+		child, exists := m.ChildrenMap[key]
+		if !exists {
+			child = NewMerger(slices.Clone(otherChild.Path))
+			if otherChild.ParentOccurrences > 0 {
+				child.ParentOccurrences = objects
+			}
+			m.ChildrenMap[key] = child
+			m.ChildrenKeys = append(m.ChildrenKeys, key)
+		}
+		child.Merge(otherChild)
+	}
+}
+
+// IsOptional reports if some of the parent objects didn't contain the key.
+// Unlike TypeNull (an explicit null value) this means the key was absent
+func (m *Merger) IsOptional() bool {
+	return m.Occurrences < m.ParentOccurrences
+}
+
+// Missing returns how much parent objects didn't contain the key
+func (m *Merger) Missing() int {
+	return max(m.ParentOccurrences-m.Occurrences, 0)
+}
+
+// Presence returns the share of parent objects that contained the key,
+// 1 for anything that isn't an object field
+func (m *Merger) Presence() float64 {
+	if m.ParentOccurrences == 0 {
+		return 1
+	}
+	return float64(m.Occurrences) / float64(m.ParentOccurrences)
+}
+
 // ComparePaths shows the difference between two paths
 func ComparePaths(path1, path2 []string) string {
 	maxLen := max(len(path1), len(path2))
@@ -114,6 +217,9 @@ func PlanToString(plan *MergePlan, indent string, isLast bool) string {
 	case PlanPrimitive:
 		sb.WriteString(prefix + "Primitive\n")
 
+	case PlanNull:
+		sb.WriteString(prefix + "Null\n")
+
 	case PlanArray:
 		strategyStr := "Collapse"
 		if plan.ArrayStrategy == ArrayKeepIndices {
@@ -5,7 +5,7 @@ import "log/slog"
 // Layer 2: Object Merge Strategy
 // All object semantics live here and ONLY here:
 // - field presence tracking
-// - nullability determination
+// - optionality determination (missing keys, not to be confused with null)
 // - independent field merging
 
 type ObjectMergeStrategy struct {
@@ -24,11 +24,18 @@ func (s *ObjectMergeStrategy) Merge(
 	fields []*FieldInfo,
 ) *Merger {
 	m := NewMerger(path)
-	m.AddTypes(label, TypeObj)
+	// non-object values (nulls) may share the path with objects
+	totalElements := 0
+	for _, f := range fields {
+		m.addField(label, f)
+		if f.Type == TypeObj {
+			totalElements++
+		}
+	}
+	m.Occurrences = len(fields)
 
 	// Group fields by name
 	fieldGroups := s.groupObjectFields(fields)
-	totalElements := len(fields)
 
 	s.logger.Debug("merging object fields",
 		"path", PathToString(path),
@@ -56,15 +63,16 @@ func (s *ObjectMergeStrategy) Merge(
 		// Merge this field's occurrences using executeMergeWithPath
 		child := executeMergeWithPath(childPlan, label, fieldInfos, childPath, s.logger)
 
-		// Apply nullability: if this field doesn't appear in all elements, it's nullable
+		// Apply optionality: if this field doesn't appear in all elements, it's optional.
+		// Missing keys are tracked by presence counters, not by TypeNull
 		appearances := len(fieldInfos)
+		child.ParentOccurrences = totalElements
 		if appearances < totalElements {
-			s.logger.Debug("marking field as nullable",
+			s.logger.Debug("marking field as optional",
 				"path", PathToString(path),
 				"field", fieldName,
 				"appearances", appearances,
 				"totalElements", totalElements)
-			child.AddTypes(label, TypeNull)
 		}
 
 		m.AddChild(fieldName, label, child)
@@ -15,6 +15,8 @@ const (
 	PlanPrimitive PlanKind = iota
 	PlanArray
 	PlanObject
+	// PlanNull is a null value, it takes the shape of whatever it's merged with
+	PlanNull
 )
 
 type ArrayStrategy int
@@ -24,6 +26,13 @@ const (
 	ArrayKeepIndices                      // use "0", "1", ...
 )
 
+func (s ArrayStrategy) String() string {
+	if s == ArrayKeepIndices {
+		return "keep-indices"
+	}
+	return "collapse"
+}
+
 // MergePlan describes the shape of the merged result
 type MergePlan struct {
 	Kind PlanKind
@@ -97,15 +106,19 @@ func planShape(field *FieldInfo, logger *slog.Logger) *MergePlan {
 			Fields: fields,
 		}
 
+	case TypeNull:
+		return &MergePlan{Kind: PlanNull}
+
 	default:
 		return &MergePlan{Kind: PlanPrimitive}
 	}
 }
 
-// unifyPlans combines multiple element plans into a single unified plan
+// unifyPlans combines multiple element plans into a single unified plan.
+// Empty arrays have no element plan, so they don't change plans of other arrays
 func unifyPlans(plans []*MergePlan) *MergePlan {
 	if len(plans) == 0 {
-		return &MergePlan{Kind: PlanPrimitive}
+		return nil
 	}
 	result := plans[0]
 	for i := range len(plans) {
@@ -117,6 +130,14 @@ func unifyPlans(plans []*MergePlan) *MergePlan {
 
 // mergeTwoPlans combines two plans into one
 func mergeTwoPlans(a, b *MergePlan) *MergePlan {
+	// Null doesn't change the shape, so nullable objects keep their fields
+	if a.Kind == PlanNull {
+		return b
+	}
+	if b.Kind == PlanNull {
+		return a
+	}
+
 	// If kinds differ, we have mixed content - treat as primitive
 	if a.Kind != b.Kind {
 		return &MergePlan{Kind: PlanPrimitive}
@@ -132,10 +153,20 @@ func mergeTwoPlans(a, b *MergePlan) *MergePlan {
 		if b.ArrayStrategy == ArrayKeepIndices {
 			strategy = ArrayKeepIndices
 		}
+		// Arrays that keep indices have per-index plans instead of an element plan
+		var fields map[string]*MergePlan
+		if len(a.Fields) > 0 || len(b.Fields) > 0 {
+			fields = make(map[string]*MergePlan)
+			maps.Copy(fields, a.Fields)
+			for k, v := range b.Fields {
+				fields[k] = mergeObjectFieldPlans(fields[k], v)
+			}
+		}
 		return &MergePlan{
 			Kind:          PlanArray,
 			ArrayStrategy: strategy,
-			Elem:          mergeTwoPlans(a.Elem, b.Elem),
+			Elem:          mergeObjectFieldPlans(a.Elem, b.Elem),
+			Fields:        fields,
 		}
 
 	case PlanObject:
@@ -53,30 +53,30 @@ func executeMergeWithPath(
 
 	switch plan.Kind {
 
-	case PlanPrimitive:
+	case PlanPrimitive, PlanNull:
 		m := NewMerger(currentPath)
 		logger.Debug("executing primitive merge",
 			"path", PathToString(currentPath),
 			"numFields", len(fields))
 
 		for _, f := range fields {
-			m.AddTypes(label, f.Type)
+			m.addField(label, f)
 		}
+		m.Occurrences = len(fields)
 		return m
 
 	case PlanArray:
 		m := NewMerger(currentPath)
-		// Determine array type from fields
-		arrayType := TypeArray
-		if len(fields) > 0 && fields[0].Type == TypeObjInt {
-			arrayType = TypeObjInt
+		// Count array types from fields
+		for _, f := range fields {
+			m.addField(label, f)
 		}
-		m.AddTypes(label, arrayType)
+		m.Occurrences = len(fields)
 		logger.Debug("executing array merge",
 			"path", PathToString(currentPath),
 			"strategy", plan.ArrayStrategy,
 			"numFields", len(fields),
-			"arrayType", arrayType)
+			"arrayType", fields[0].Type)
 
 		// Group array elements according to strategy
 		buckets := groupArrayElements(fields, plan.ArrayStrategy, logger)
@@ -98,14 +98,15 @@ func executeMergeWithPath(
 			if plan.ArrayStrategy == ArrayKeepIndices && plan.Fields != nil {
 				// Use the specific plan for this index
 				childPlan = plan.Fields[key]
-				if childPlan == nil {
-					// Fallback to primitive if no plan exists
-					childPlan = &MergePlan{Kind: PlanPrimitive}
-				}
-			} else {
+			}
+			if childPlan == nil {
 				// Use the unified element plan
 				childPlan = plan.Elem
 			}
+			if childPlan == nil {
+				// Fallback to primitive if no plan exists
+				childPlan = &MergePlan{Kind: PlanPrimitive}
+			}
 
 			child := executeMergeWithPath(childPlan, label, elems, childPath, logger)
 			m.AddChild(key, label, child)
@@ -126,8 +127,9 @@ func executeMergeWithPath(
 			"kind", plan.Kind)
 		m := NewMerger(currentPath)
 		for _, f := range fields {
-			m.AddTypes(label, f.Type)
+			m.addField(label, f)
 		}
+		m.Occurrences = len(fields)
 		return m
 	}
 }
@@ -206,14 +208,10 @@ func MergeFieldInfo(m *Merger, label string, field *FieldInfo, logger *slog.Logg
 	// Step 2: Execute the plan - start with the field's actual path
 	result := executeMergeWithPath(plan, label, []*FieldInfo{field}, field.Path, logger)
 
-	// If a merger was provided, merge into it
+	// If a merger was provided, merge into it.
+	// Merge also counts objects of the document as parents of keys it lacks
 	if m != nil {
-		for t := range result.TypesMap {
-			m.AddTypes(label, t)
-		}
-		for _, key := range result.ChildrenKeys {
-			m.AddChild(key, label, result.ChildrenMap[key])
-		}
+		m.Merge(result)
 		return m
 	}
 
@@ -0,0 +1,406 @@
+package jsontype
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// Streaming merge
+// Parsed values are aggregated per path as they arrive instead of building a FieldInfo tree,
+// so memory depends on the number of distinct paths rather than on the document size.
+// A MergePlan is built along the way the same way PlanShape builds it from a tree,
+// and once the document is parsed the aggregated shape is converted into a Merger
+// following that plan like the merge executor does.
+// The only difference: arrays (and objects with integer keys) with more indices
+// than MaxTupleLength are always collapsed
+
+// DefaultMaxTupleLength is the default for ParseOptions.MaxTupleLength
+const DefaultMaxTupleLength = 32
+
+// MergeStream parses the stream straight into m under the label without building
+// a FieldInfo tree. If m is nil a new Merger is returned.
+// On errors m is left as is, unless the options ask for partial results
+func MergeStream(m *Merger, label string, s Stream, opts ...ParseOption) (*Merger, error) {
+	return MergeStreamWithOptions(m, label, s, NewParseOptions(opts...))
+}
+
+// MergeStreamWithOptions is MergeStream taking options as a struct
+func MergeStreamWithOptions(m *Merger, label string, s Stream, o ParseOptions) (*Merger, error) {
+	sink := &mergeSink{
+		maxTupleLength: o.MaxTupleLength,
+		label:          label,
+		perDocument:    o.Concatenated,
+		documentLabels: o.DocumentLabels,
+	}
+	if sink.maxTupleLength <= 0 {
+		sink.maxTupleLength = DefaultMaxTupleLength
+	}
+	err := parseInto(sink, s, o)
+	if err != nil {
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			parseErr.Label = label
+		}
+		if !o.Partial {
+			return nil, err
+		}
+	}
+	result := sink.result()
+	if result == nil {
+		if m == nil {
+			m = NewMerger([]string{})
+		}
+		return m, err
+	}
+	if m == nil {
+		return result, err
+	}
+	m.Merge(result)
+	return m, err
+}
+
+// shapeNode aggregates every value met at a path.
+// Array elements are kept per index until the merger is built,
+// then they are either merged together or kept as a tuple, as the plan says
+type shapeNode struct {
+	types   map[DetectedType]int
+	decimal DecimalInfo
+	// integer types of values detected as unix timestamps
+	epochIntTypes map[DetectedType]int
+	// fields of objects at the path
+	fields    map[string]*shapeNode
+	fieldKeys []string
+	// elements of arrays (and objects with integer keys) at the path
+	elems    map[string]*shapeNode
+	elemKeys []string
+	// elements are only kept under "", some array at the path was too long to be a tuple
+	collapsed bool
+}
+
+func newShapeNode() *shapeNode {
+	return &shapeNode{
+		types:  make(map[DetectedType]int),
+		fields: make(map[string]*shapeNode),
+		elems:  make(map[string]*shapeNode),
+	}
+}
+
+// field returns the node of an object field, creating it if needed
+func (n *shapeNode) field(key string) *shapeNode {
+	ch, exists := n.fields[key]
+	if !exists {
+		ch = newShapeNode()
+		n.fields[key] = ch
+		n.fieldKeys = append(n.fieldKeys, key)
+	}
+	return ch
+}
+
+// elem returns the node for an array element, collapsing the array
+// once it has more indices than a tuple may have
+func (n *shapeNode) elem(key string, maxTupleLength int) *shapeNode {
+	if n.collapsed {
+		key = ""
+	} else if _, exists := n.elems[key]; !exists && len(n.elems) >= maxTupleLength {
+		n.collapse(maxTupleLength)
+		key = ""
+	}
+	ch, exists := n.elems[key]
+	if !exists {
+		ch = newShapeNode()
+		n.elems[key] = ch
+		n.elemKeys = append(n.elemKeys, key)
+	}
+	return ch
+}
+
+// collapse merges elements of every index under ""
+func (n *shapeNode) collapse(maxTupleLength int) {
+	n.elems = map[string]*shapeNode{"": n.mergedElems(maxTupleLength)}
+	n.elemKeys = []string{""}
+	n.collapsed = true
+}
+
+// mergedElems returns elements of every index merged together
+func (n *shapeNode) mergedElems(maxTupleLength int) *shapeNode {
+	if len(n.elemKeys) == 1 {
+		return n.elems[n.elemKeys[0]]
+	}
+	merged := newShapeNode()
+	for _, key := range n.elemKeys {
+		merged.merge(n.elems[key], maxTupleLength)
+	}
+	return merged
+}
+
+func (n *shapeNode) addEpochIntType(t DetectedType, count int) {
+	if n.epochIntTypes == nil {
+		n.epochIntTypes = make(map[DetectedType]int)
+	}
+	n.epochIntTypes[t] += count
+}
+
+// merge adds everything aggregated by other
+func (n *shapeNode) merge(other *shapeNode, maxTupleLength int) {
+	for t, count := range other.types {
+		n.types[t] += count
+	}
+	n.decimal = n.decimal.Widen(other.decimal)
+	for t, count := range other.epochIntTypes {
+		n.addEpochIntType(t, count)
+	}
+	for _, key := range other.fieldKeys {
+		n.field(key).merge(other.fields[key], maxTupleLength)
+	}
+	if other.collapsed && !n.collapsed && len(n.elemKeys) > 0 {
+		n.collapse(maxTupleLength)
+	}
+	n.collapsed = n.collapsed || other.collapsed
+	for _, key := range other.elemKeys {
+		n.elem(key, maxTupleLength).merge(other.elems[key], maxTupleLength)
+	}
+}
+
+// toMerger mirrors executeMergeWithPath: values aggregated at the path are converted
+// following the plan built for them, a nil plan merges them as primitives
+func (n *shapeNode) toMerger(plan *MergePlan, path []string, label string, maxTupleLength int) *Merger {
+	m := NewMerger(path)
+	for _, t := range collectTypes(n.types) {
+		m.AddTypeCount(label, t, n.types[t])
+		m.Occurrences += n.types[t]
+	}
+	m.Decimal = n.decimal
+	for t, count := range n.epochIntTypes {
+		m.addEpochIntType(label, t, count)
+	}
+	if plan == nil {
+		return m
+	}
+
+	switch plan.Kind {
+	case PlanArray:
+		if len(n.elemKeys) == 0 {
+			break
+		}
+		if plan.ArrayStrategy == ArrayKeepIndices && !n.collapsed {
+			for _, key := range n.elemKeys {
+				elemPlan := plan.Fields[key]
+				if elemPlan == nil {
+					elemPlan = plan.Elem
+				}
+				m.AddChild(key, label, n.elems[key].toMerger(elemPlan, childPath(path, key), label, maxTupleLength))
+			}
+			break
+		}
+		elemPlan := plan.Elem
+		if plan.ArrayStrategy == ArrayKeepIndices {
+			// tuples were too long to keep, their elements share a plan
+			for _, key := range slices.Sorted(maps.Keys(plan.Fields)) {
+				elemPlan = mergeObjectFieldPlans(elemPlan, plan.Fields[key])
+			}
+		}
+		elem := n.mergedElems(maxTupleLength)
+		m.AddChild("", label, elem.toMerger(elemPlan, childPath(path, ""), label, maxTupleLength))
+
+	case PlanObject:
+		objects := n.types[TypeObj]
+		for _, key := range n.fieldKeys {
+			child := n.fields[key].toMerger(plan.Fields[key], childPath(path, key), label, maxTupleLength)
+			child.ParentOccurrences = objects
+			m.AddChild(key, label, child)
+		}
+	}
+	return m
+}
+
+func childPath(path []string, key string) []string {
+	return append(slices.Clone(path), key)
+}
+
+// leafPlan is the plan of a value that isn't entered as a container
+func leafPlan(t DetectedType) *MergePlan {
+	switch t {
+	case TypeNull:
+		return &MergePlan{Kind: PlanNull}
+	case TypeObj:
+		// an empty object
+		return &MergePlan{Kind: PlanObject, Fields: map[string]*MergePlan{}}
+	default:
+		return &MergePlan{Kind: PlanPrimitive}
+	}
+}
+
+// shapeFrame is a container being parsed, its plan is built like PlanShape does
+// once the container is left
+type shapeFrame struct {
+	node    *shapeNode
+	key     string
+	isArray bool
+	// type of the first non-null element, used to tell if the array is mixed
+	firstType DetectedType
+	mixed     bool
+	// plans of array elements by index, dropped once the array is too long to be a tuple
+	elemPlans map[string]*MergePlan
+	tooLong   bool
+	// unified plan of array elements or plans of object fields
+	elem   *MergePlan
+	fields map[string]*MergePlan
+}
+
+// addPlan records the plan of a child value
+func (f *shapeFrame) addPlan(key string, plan *MergePlan, maxTupleLength int) {
+	if !f.isArray {
+		f.fields[key] = mergeObjectFieldPlans(f.fields[key], plan)
+		return
+	}
+	f.elem = mergeObjectFieldPlans(f.elem, plan)
+	if f.tooLong {
+		return
+	}
+	f.elemPlans[key] = plan
+	if len(f.elemPlans) > maxTupleLength {
+		f.elemPlans, f.tooLong = nil, true
+	}
+}
+
+// plan mirrors planShape for the container
+func (f *shapeFrame) plan() *MergePlan {
+	switch {
+	case !f.isArray:
+		return &MergePlan{Kind: PlanObject, Fields: f.fields}
+	case f.mixed && !f.tooLong:
+		return &MergePlan{Kind: PlanArray, ArrayStrategy: ArrayKeepIndices, Fields: f.elemPlans}
+	default:
+		return &MergePlan{Kind: PlanArray, ArrayStrategy: ArrayCollapse, Elem: f.elem}
+	}
+}
+
+// mergeSink aggregates parsed values into shape nodes
+type mergeSink struct {
+	root *shapeNode
+	// plan of the root value, set once it's complete
+	plan           *MergePlan
+	stack          []shapeFrame
+	maxTupleLength int
+	label          string
+	// concatenated values are converted one by one and merged into documents like separate inputs,
+	// so objects and arrays at the root keep their children
+	perDocument bool
+	documents   *Merger
+	// label documents as "label#N"
+	documentLabels bool
+	// index of the current document
+	document int
+}
+
+func (s *mergeSink) value(path []string, t DetectedType, decimal DecimalInfo, intType DetectedType) {
+	node := s.add(path, t)
+	node.decimal = node.decimal.Widen(decimal)
+	if intType != "" {
+		node.addEpochIntType(intType, 1)
+	}
+	s.addPlan(lastPathSegment(path), leafPlan(t))
+}
+
+func (s *mergeSink) enter(path []string, t DetectedType) {
+	frame := shapeFrame{
+		node:    s.add(path, t),
+		key:     lastPathSegment(path),
+		isArray: t == TypeArray || t == TypeObjInt,
+	}
+	if frame.isArray {
+		frame.elemPlans = make(map[string]*MergePlan)
+	} else {
+		frame.fields = make(map[string]*MergePlan)
+	}
+	s.stack = append(s.stack, frame)
+}
+
+func (s *mergeSink) leave() {
+	frame := s.stack[len(s.stack)-1]
+	s.stack = s.stack[:len(s.stack)-1]
+	s.addPlan(frame.key, frame.plan())
+}
+
+// addPlan passes the plan of a complete value to its container
+func (s *mergeSink) addPlan(key string, plan *MergePlan) {
+	if len(s.stack) == 0 {
+		s.plan = plan
+		return
+	}
+	s.stack[len(s.stack)-1].addPlan(key, plan, s.maxTupleLength)
+}
+
+func (s *mergeSink) endDocument() {
+	if s.perDocument {
+		s.flushDocument()
+	}
+	s.document++
+}
+
+// flushDocument converts the current document and merges it into documents
+func (s *mergeSink) flushDocument() {
+	if s.root == nil {
+		return
+	}
+	if s.documents == nil {
+		s.documents = NewMerger([]string{})
+	}
+	label := s.label
+	if s.documentLabels {
+		label = fmt.Sprintf("%s#%d", s.label, s.document)
+	}
+	s.documents.Merge(s.convert(label))
+	s.root, s.plan, s.stack = nil, nil, nil
+}
+
+// convert plans the containers left open by an error and converts the root
+func (s *mergeSink) convert(label string) *Merger {
+	for len(s.stack) > 0 {
+		s.leave()
+	}
+	return s.root.toMerger(s.plan, []string{}, label, s.maxTupleLength)
+}
+
+// result converts everything parsed so far, nil if nothing was
+func (s *mergeSink) result() *Merger {
+	if s.perDocument {
+		// the prefix of a failing document
+		s.flushDocument()
+		return s.documents
+	}
+	if s.root == nil {
+		return nil
+	}
+	return s.convert(s.label)
+}
+
+func (s *mergeSink) add(path []string, t DetectedType) *shapeNode {
+	if len(s.stack) == 0 {
+		if s.root == nil {
+			s.root = newShapeNode()
+		}
+		s.root.types[t]++
+		return s.root
+	}
+	frame := &s.stack[len(s.stack)-1]
+	key := lastPathSegment(path)
+	var node *shapeNode
+	if frame.isArray {
+		// null elements don't make an array mixed
+		if t != TypeNull {
+			if frame.firstType == "" {
+				frame.firstType = t
+			} else if frame.firstType != t {
+				frame.mixed = true
+			}
+		}
+		node = frame.node.elem(key, s.maxTupleLength)
+	} else {
+		node = frame.node.field(key)
+	}
+	node.types[t]++
+	return node
+}
@@ -0,0 +1,328 @@
+package jsontype_test
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/4nd3r5on/jsontype"
+)
+
+// canonicalMerger renders the tree with sorted children and counters
+func canonicalMerger(m *jsontype.Merger) string {
+	var sb strings.Builder
+	var walk func(m *jsontype.Merger)
+	walk = func(m *jsontype.Merger) {
+		types := make([]string, 0, len(m.TypesMap))
+		for t, n := range m.TypesMap {
+			types = append(types, fmt.Sprintf("%s:%d", t, n))
+		}
+		slices.Sort(types)
+		fmt.Fprintf(&sb, "%s %v occ=%d/%d\n", jsontype.PathToString(m.Path), types, m.Occurrences, m.ParentOccurrences)
+		keys := slices.Clone(m.ChildrenKeys)
+		slices.Sort(keys)
+		for _, key := range keys {
+			walk(m.ChildrenMap[key])
+		}
+	}
+	walk(m)
+	return sb.String()
+}
+
+// randomJSON generates a value mixing kinds at the same paths: few keys are reused,
+// arrays are short enough to stay tuples, objects may have integer or duplicate keys
+func randomJSON(r *rand.Rand, depth int) string {
+	primitives := []string{`null`, `true`, `1`, `3000000000`, `1.5`, `"x"`, `"2024-01-02T15:04:05Z"`}
+	kind := r.Intn(4)
+	if depth == 0 {
+		kind = 0
+	}
+	switch kind {
+	case 0, 1:
+		return primitives[r.Intn(len(primitives))]
+	case 2:
+		elems := make([]string, r.Intn(5))
+		for i := range elems {
+			elems[i] = randomJSON(r, depth-1)
+		}
+		return "[" + strings.Join(elems, ",") + "]"
+	default:
+		keys := []string{"a", "b", "c"}
+		if r.Intn(4) == 0 {
+			keys = []string{"1", "2", "3", "a"}
+		}
+		fields := make([]string, r.Intn(4))
+		for i := range fields {
+			fields[i] = fmt.Sprintf("%q:%s", keys[r.Intn(len(keys))], randomJSON(r, depth-1))
+		}
+		return "{" + strings.Join(fields, ",") + "}"
+	}
+}
+
+// TestMergeStream_MatchesFieldInfoMerge compares the streaming merge with Parse followed by
+// MergeFieldInfo on random documents, single values and NDJSON records
+func TestMergeStream_MatchesFieldInfoMerge(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	docs := []string{
+		// arrays mixed with primitives at the same path keep the children the planner keeps
+		`[[[[3,3],4],[0]]]`,
+		`[[[2,[]]],[[],null,3]]`,
+		`[{"tags": [{"a": 1}]}, {"tags": []}]`,
+	}
+	r := rand.New(rand.NewSource(1))
+	for range 3000 {
+		docs = append(docs, randomJSON(r, 5))
+	}
+
+	for i, doc := range docs {
+		opts := []jsontype.ParseOption{jsontype.WithLogger(logger)}
+		if i%2 == 1 {
+			records := []string{doc}
+			for range r.Intn(4) {
+				records = append(records, randomJSON(r, 4))
+			}
+			doc = strings.Join(records, "\n")
+			opts = append(opts, jsontype.WithNDJSON())
+		}
+
+		root, err := jsontype.Parse(jsontype.NewJSONStream(strings.NewReader(doc)), opts...)
+		if err != nil {
+			t.Fatalf("doc %d: parse: %v", i, err)
+		}
+		expected := canonicalMerger(jsontype.MergeFieldInfo(jsontype.NewMerger([]string{}), "test", root, logger))
+
+		merger, err := jsontype.MergeStream(jsontype.NewMerger([]string{}), "test",
+			jsontype.NewJSONStream(strings.NewReader(doc)), opts...)
+		if err != nil {
+			t.Fatalf("doc %d: merge stream: %v", i, err)
+		}
+		if got := canonicalMerger(merger); got != expected {
+			t.Fatalf("doc %d: trees differ for %s\nGot:\n%s\nExpected:\n%s", i, doc, got, expected)
+		}
+	}
+}
+
+// TestMergeStream_MatchesFieldInfoMergeSequential merges documents one after another into a
+// single merger through both paths, so keys missing from later documents become optional
+func TestMergeStream_MatchesFieldInfoMergeSequential(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	docs := []string{`{"a":1,"b":2}`, `{"a":1}`}
+	r := rand.New(rand.NewSource(2))
+	for range 200 {
+		docs = append(docs, randomJSON(r, 4))
+	}
+
+	viaFieldInfo := jsontype.NewMerger([]string{})
+	viaStream := jsontype.NewMerger([]string{})
+	for i, doc := range docs {
+		root, err := jsontype.Parse(jsontype.NewJSONStream(strings.NewReader(doc)), jsontype.WithLogger(logger))
+		if err != nil {
+			t.Fatalf("doc %d: parse: %v", i, err)
+		}
+		jsontype.MergeFieldInfo(viaFieldInfo, "test", root, logger)
+
+		if _, err := jsontype.MergeStream(viaStream, "test", jsontype.NewJSONStream(strings.NewReader(doc)),
+			jsontype.WithLogger(logger)); err != nil {
+			t.Fatalf("doc %d: merge stream: %v", i, err)
+		}
+
+		if i == 1 {
+			b := viaFieldInfo.ChildrenMap["b"]
+			if b == nil || b.Occurrences != 1 || b.ParentOccurrences != 2 {
+				t.Fatalf("expected $.b to be present in 1 of 2 objects, got %+v", b)
+			}
+		}
+		if got, expected := canonicalMerger(viaStream), canonicalMerger(viaFieldInfo); got != expected {
+			t.Fatalf("after doc %d (%s) trees differ\nGot:\n%s\nExpected:\n%s", i, doc, got, expected)
+		}
+	}
+}
+
+func TestMergeStream_LongArraysCollapse(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	// a mixed array longer than a tuple is collapsed
+	var sb strings.Builder
+	sb.WriteString("[")
+	for i := range 100 {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		if i%2 == 0 {
+			fmt.Fprintf(&sb, `{"id": %d}`, i)
+		} else {
+			sb.WriteString(`"x"`)
+		}
+	}
+	sb.WriteString("]")
+
+	merger, err := jsontype.MergeStream(nil, "test",
+		jsontype.NewJSONStream(strings.NewReader(sb.String())),
+		jsontype.WithLogger(logger), jsontype.WithMaxTupleLength(8))
+	if err != nil {
+		t.Fatalf("merge stream: %v", err)
+	}
+	if len(merger.ChildrenKeys) != 1 || merger.ChildrenKeys[0] != "" {
+		t.Fatalf("expected the array to collapse, got children %v", merger.ChildrenKeys)
+	}
+	elem := merger.ChildrenMap[""]
+	if elem.TypesMap[jsontype.TypeObj] != 50 || elem.TypesMap[jsontype.TypeString] != 50 {
+		t.Errorf("expected 50 objects and 50 strings, got %v", elem.TypesMap)
+	}
+}
+
+// benchmarkRecords returns NDJSON records with nested objects and arrays
+func benchmarkRecords(n int) string {
+	var sb strings.Builder
+	for i := range n {
+		fmt.Fprintf(&sb, `{"id": %d, "email": "user%d@example.com", "created": "2024-01-02T15:04:05Z", `+
+			`"tags": ["a", "b", "c"], "address": {"city": "Berlin", "zip": "10115", "geo": [52.52, 13.405]}, `+
+			`"orders": [{"sku": "A-%d", "qty": 2, "price": 9.99}, {"sku": "B-%d", "qty": 1, "price": 19.5, "note": null}]}`+"\n",
+			i, i, i, i)
+	}
+	return sb.String()
+}
+
+func BenchmarkMergeFieldInfo(b *testing.B) {
+	input := benchmarkRecords(1000)
+	logger := slog.New(slog.DiscardHandler)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	for b.Loop() {
+		root, err := jsontype.Parse(jsontype.NewJSONStream(strings.NewReader(input)),
+			jsontype.WithNDJSON(), jsontype.WithLogger(logger))
+		if err != nil {
+			b.Fatal(err)
+		}
+		jsontype.MergeFieldInfo(nil, "bench", root, logger)
+	}
+}
+
+func BenchmarkMergeStream(b *testing.B) {
+	input := benchmarkRecords(1000)
+	logger := slog.New(slog.DiscardHandler)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	for b.Loop() {
+		_, err := jsontype.MergeStream(nil, "bench", jsontype.NewJSONStream(strings.NewReader(input)),
+			jsontype.WithNDJSON(), jsontype.WithLogger(logger))
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestMergeStream_Partial(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	input := "{\"a\": 1, \"b\": \"x\"}\n{\"a\": 2, \"c\": [1, 2,"
+
+	m := jsontype.NewMerger([]string{})
+	if _, err := jsontype.MergeStream(m, "skipped", jsontype.NewJSONStream(strings.NewReader(input)),
+		jsontype.WithLogger(logger), jsontype.WithNDJSON()); err == nil {
+		t.Fatal("expected an error")
+	}
+	if len(m.TypesMap) != 0 {
+		t.Errorf("failed document must not be merged without WithPartial: %v", m.TypesMap)
+	}
+
+	merged, err := jsontype.MergeStream(m, "partial", jsontype.NewJSONStream(strings.NewReader(input)),
+		jsontype.WithLogger(logger), jsontype.WithNDJSON(), jsontype.WithPartial())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if merged != m {
+		t.Fatal("expected the partial result merged into m")
+	}
+	record := m.ChildrenMap[""]
+	if record == nil || record.ChildrenMap["a"].Occurrences != 2 {
+		t.Fatalf("expected both records merged")
+	}
+	if elem := record.ChildrenMap["c"].ChildrenMap[""]; elem == nil || elem.TypesMap[jsontype.TypeInt32] != 2 {
+		t.Errorf("expected elements parsed before the error, got %v", record.ChildrenMap["c"])
+	}
+}
+
+func TestInputErrors(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	var errs jsontype.InputErrors
+	for label, input := range map[string]string{"b.json": "{\n\"a\": tru}", "a.json": "[1,"} {
+		_, err := jsontype.MergeStream(nil, label, jsontype.NewJSONStream(strings.NewReader(input)), jsontype.WithLogger(logger))
+		errs.Add(label, err)
+	}
+	errs.Add("c.json", fmt.Errorf("open c.json: permission denied"))
+
+	var sb strings.Builder
+	if err := errs.WriteSummary(&sb); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(sb.String()), "\n")
+	want := []string{
+		"failed inputs: 3",
+		"INPUT   POSITION  ERROR",
+		"a.json  1:3       ",
+		"b.json  2:9       ",
+		"c.json  -         open c.json: permission denied",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got summary\n%s", sb.String())
+	}
+	for i, prefix := range want {
+		if !strings.HasPrefix(lines[i], prefix) {
+			t.Errorf("line %d: got %q, want prefix %q", i, lines[i], prefix)
+		}
+	}
+}
+
+func TestMergeStream_Concatenated(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	input := `{"type": "start", "id": 1}{"type": "stop", "id": 2, "extra": {"a": 1}}[1, 2]
+		{"type": "die", "id": 3}`
+
+	merger, err := jsontype.MergeStream(nil, "events", jsontype.NewJSONStream(strings.NewReader(input)),
+		jsontype.WithLogger(logger), jsontype.WithConcatenated())
+	if err != nil {
+		t.Fatalf("merge stream: %v", err)
+	}
+	if merger.TypesMap[jsontype.TypeObj] != 3 || merger.TypesMap[jsontype.TypeArray] != 1 {
+		t.Errorf("expected 3 objects and 1 array at the root, got %v", merger.TypesMap)
+	}
+	if id := merger.ChildrenMap["id"]; id == nil || id.Occurrences != 3 || id.IsOptional() {
+		t.Errorf("expected id in every object, got %+v", id)
+	}
+	if extra := merger.ChildrenMap["extra"]; extra == nil || !extra.IsOptional() {
+		t.Errorf("expected optional extra, got %+v", extra)
+	}
+	if elem := merger.ChildrenMap[""]; elem == nil || elem.TypesMap[jsontype.TypeInt32] != 2 {
+		t.Errorf("expected array elements at the root, got %+v", elem)
+	}
+	if labels := slices.Sorted(maps.Keys(merger.LabeledTypesMap)); !slices.Equal(labels, []string{"events"}) {
+		t.Errorf("expected a single label, got %v", labels)
+	}
+
+	labeled, err := jsontype.MergeStream(nil, "events", jsontype.NewJSONStream(strings.NewReader(input)),
+		jsontype.WithLogger(logger), jsontype.WithDocumentLabels())
+	if err != nil {
+		t.Fatalf("merge stream: %v", err)
+	}
+	want := []string{"events#0", "events#1", "events#2", "events#3"}
+	if labels := slices.Sorted(maps.Keys(labeled.LabeledTypesMap)); !slices.Equal(labels, want) {
+		t.Errorf("got labels %v, want %v", labels, want)
+	}
+
+	docs, err := jsontype.ParseDocuments(jsontype.NewJSONStream(strings.NewReader(input)), jsontype.WithLogger(logger))
+	if err != nil {
+		t.Fatalf("parse documents: %v", err)
+	}
+	if len(docs) != 4 || docs[2].Type != jsontype.TypeArray {
+		t.Errorf("expected 4 documents with an array third, got %d", len(docs))
+	}
+
+	_, err = jsontype.MergeStream(nil, "events", jsontype.NewJSONStream(strings.NewReader(`{}[]{"a": }`)),
+		jsontype.WithLogger(logger), jsontype.WithConcatenated())
+	if err == nil || !strings.Contains(err.Error(), "document 2") {
+		t.Errorf("expected an error of document 2, got %v", err)
+	}
+}
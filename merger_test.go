@@ -1,8 +1,11 @@
 package jsontype_test
 
 import (
+	"fmt"
 	"log/slog"
 	"os"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/4nd3r5on/jsontype"
@@ -66,10 +69,13 @@ func TestSimpleArrayOfObjects(t *testing.T) {
 		t.Errorf("Field 'y' is missing!")
 	}
 
-	// y should be nullable
+	// y should be optional, but not nullable
 	if hasY {
-		if _, hasNull := yField.TypesMap[jsontype.TypeNull]; !hasNull {
-			t.Errorf("Field 'y' should be nullable")
+		if !yField.IsOptional() {
+			t.Errorf("Field 'y' should be optional")
+		}
+		if _, hasNull := yField.TypesMap[jsontype.TypeNull]; hasNull {
+			t.Errorf("Field 'y' was never null")
 		}
 	}
 
@@ -193,7 +199,7 @@ func TestArrayOfArraysOfObjects(t *testing.T) {
 	//       ["array_of_arrays_of_objects", "", ""] => object<int32>
 	//         ["array_of_arrays_of_objects", "", "", "x"] => int32
 	//         ["array_of_arrays_of_objects", "", "", "y"] => int32
-	//         ["array_of_arrays_of_objects", "", "", "z"] => int32 | null
+	//         ["array_of_arrays_of_objects", "", "", "z"]? => int32
 
 	// Check root is object
 	if _, hasObj := merger.TypesMap[jsontype.TypeObj]; !hasObj {
@@ -254,13 +260,13 @@ func TestArrayOfArraysOfObjects(t *testing.T) {
 		}
 	}
 
-	// Verify z is int32 | null (appears in 1 of 6 objects)
+	// Verify z is optional int32 (appears in 1 of 6 objects)
 	if hasZ {
 		if _, hasInt := zField.TypesMap[jsontype.TypeInt32]; !hasInt {
 			t.Errorf("Expected z to be int32")
 		}
-		if _, hasNull := zField.TypesMap[jsontype.TypeNull]; !hasNull {
-			t.Errorf("Expected z to be nullable (doesn't appear in all objects)")
+		if !zField.IsOptional() || zField.Missing() != 5 {
+			t.Errorf("Expected z to be optional (missing from 5 objects), got %d missing", zField.Missing())
 		}
 	}
 
@@ -276,7 +282,7 @@ func TestArrayOfArraysOfObjects(t *testing.T) {
 
 	t.Logf("✓ Bug is fixed: object fields preserved as x, y, z (not collapsed)")
 	t.Logf("✓ Structure: array ▸ array ▸ object{x, y, z}")
-	t.Logf("✓ Field z is correctly nullable")
+	t.Logf("✓ Field z is correctly optional")
 }
 
 // TestPlanShape_ObjectsNeverCollapse verifies the core law
@@ -339,3 +345,221 @@ func TestPlanShape_ObjectsNeverCollapse(t *testing.T) {
 	t.Logf("✓ Core law verified: Objects never collapse keys")
 	t.Logf("✓ Even when nested under non-mixed arrays")
 }
+
+// TestPlanShape_EmptyArrays verifies empty arrays don't turn element plans of other
+// arrays into primitives. They used to plan as a primitive element, which conflicted
+// with the object elements and dropped their fields
+func TestPlanShape_EmptyArrays(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	root, err := jsontype.Parse(jsontype.NewJSONStream(strings.NewReader(`[{"tags": [{"a": 1}]}, {"tags": []}]`)),
+		jsontype.WithLogger(logger))
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	plan := jsontype.PlanShape(root, logger)
+	tags := plan.Elem.Fields["tags"]
+	if tags.Kind != jsontype.PlanArray || tags.Elem == nil || tags.Elem.Kind != jsontype.PlanObject {
+		t.Fatalf("expected tags to be an array of objects, got:\n%s", jsontype.PlanToString(plan, "", true))
+	}
+
+	merger := jsontype.MergeFieldInfo(nil, "test", root, logger)
+	if tree := jsontype.MergerToString(merger, "", true); !strings.Contains(tree, "$[].tags[].a") {
+		t.Errorf("expected $[].tags[].a in the merged tree, got:\n%s", tree)
+	}
+}
+
+// TestMergerCounts verifies per-path type counters and presence
+func TestMergerCounts(t *testing.T) {
+	merger := mergeJSON(t, `[
+		{"id": 1, "email": "a@b.com"},
+		{"id": 2, "email": null},
+		{"id": 3},
+		{"id": "4", "email": "c@d.com"}
+	]`)
+
+	elem := merger.ChildrenMap[""]
+	if elem.Occurrences != 4 {
+		t.Errorf("expected 4 elements, got %d", elem.Occurrences)
+	}
+	if n := elem.TypesMap[jsontype.TypeObj]; n != 4 {
+		t.Errorf("expected object to be counted 4 times, got %d", n)
+	}
+
+	id := elem.ChildrenMap["id"]
+	if id.TypesMap[jsontype.TypeInt32] != 3 || id.TypesMap[jsontype.TypeString] != 1 {
+		t.Errorf("unexpected id counts: %v", id.TypesMap)
+	}
+
+	email := elem.ChildrenMap["email"]
+	if email.Occurrences != 3 || email.ParentOccurrences != 4 {
+		t.Errorf("expected email to be present in 3 of 4 objects, got %d of %d",
+			email.Occurrences, email.ParentOccurrences)
+	}
+	if presence := email.Presence(); presence != 0.75 {
+		t.Errorf("expected presence 0.75, got %v", presence)
+	}
+
+	var sb strings.Builder
+	jsontype.PrintMergerTree(merger, "", &sb)
+	t.Log("\n" + sb.String())
+	if !strings.Contains(sb.String(), "$[].id => int32 (75%) | string (25%)\n") {
+		t.Errorf("expected percentages in the tree output")
+	}
+}
+
+// TestMissingVsNull verifies that absent keys and explicit nulls are told apart
+func TestMissingVsNull(t *testing.T) {
+	merger := mergeJSON(t, `[
+		{"missing": 1, "null": null, "profile": {"name": "x"}},
+		{"null": 2, "profile": null}
+	]`)
+	elem := merger.ChildrenMap[""]
+
+	missing := elem.ChildrenMap["missing"]
+	if !missing.IsOptional() {
+		t.Errorf("'missing' should be optional")
+	}
+	if _, hasNull := missing.TypesMap[jsontype.TypeNull]; hasNull {
+		t.Errorf("'missing' was never null")
+	}
+
+	null := elem.ChildrenMap["null"]
+	if null.IsOptional() {
+		t.Errorf("'null' is present in every object")
+	}
+	if _, hasNull := null.TypesMap[jsontype.TypeNull]; !hasNull {
+		t.Errorf("'null' should be nullable")
+	}
+
+	// null objects keep the shape of the non-null ones
+	profile := elem.ChildrenMap["profile"]
+	if _, hasName := profile.ChildrenMap["name"]; !hasName {
+		t.Errorf("nullable object lost its fields")
+	}
+
+	var sb strings.Builder
+	jsontype.PrintMergerTree(merger, "", &sb)
+	t.Log("\n" + sb.String())
+	if !strings.Contains(sb.String(), "$[].missing? => int32 [present in 50%]\n") {
+		t.Errorf("expected optional marker in the tree output")
+	}
+}
+
+func TestMergerMerge_Associative(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	docs := map[string]string{
+		"a": `{"id": 1, "tags": ["x"], "user": {"name": "n"}}`,
+		"b": `{"user": {"email": "e@mail.com", "name": null}, "extra": [1, "x"]}`,
+		"c": `[{"id": 2}]`,
+	}
+	parsed := func(label string) *jsontype.Merger {
+		m, err := jsontype.MergeStream(nil, label,
+			jsontype.NewJSONStream(strings.NewReader(docs[label])), jsontype.WithLogger(logger))
+		if err != nil {
+			t.Fatalf("merge %s: %v", label, err)
+		}
+		return m
+	}
+	// dump keeps ChildrenKeys order and per-label counters
+	var dump func(m *jsontype.Merger, sb *strings.Builder)
+	dump = func(m *jsontype.Merger, sb *strings.Builder) {
+		labels := make([]string, 0, len(m.LabeledTypesMap))
+		for label, types := range m.LabeledTypesMap {
+			for typ, n := range types {
+				labels = append(labels, fmt.Sprintf("%s/%s:%d", label, typ, n))
+			}
+		}
+		slices.Sort(labels)
+		fmt.Fprintf(sb, "%s %v occ=%d/%d\n", jsontype.PathToString(m.Path), labels, m.Occurrences, m.ParentOccurrences)
+		for _, key := range m.ChildrenKeys {
+			dump(m.ChildrenMap[key], sb)
+		}
+	}
+	render := func(m *jsontype.Merger) string {
+		var sb strings.Builder
+		dump(m, &sb)
+		return sb.String()
+	}
+
+	// (a + b) + c
+	left := jsontype.NewMerger([]string{})
+	left.Merge(parsed("a"))
+	left.Merge(parsed("b"))
+	left.Merge(parsed("c"))
+
+	// a + (b + c)
+	bc := parsed("b")
+	bc.Merge(parsed("c"))
+	right := parsed("a")
+	right.Merge(bc)
+
+	// sequential merge into a single tree
+	sequential := jsontype.NewMerger([]string{})
+	for _, label := range []string{"a", "b", "c"} {
+		if _, err := jsontype.MergeStream(sequential, label,
+			jsontype.NewJSONStream(strings.NewReader(docs[label])), jsontype.WithLogger(logger)); err != nil {
+			t.Fatalf("merge %s: %v", label, err)
+		}
+	}
+
+	expected := render(sequential)
+	if got := render(left); got != expected {
+		t.Errorf("(a+b)+c differs\nGot:\n%s\nExpected:\n%s", got, expected)
+	}
+	if got := render(right); got != expected {
+		t.Errorf("a+(b+c) differs\nGot:\n%s\nExpected:\n%s", got, expected)
+	}
+
+	// the merged tree must not be modified
+	c := parsed("c")
+	before := render(c)
+	jsontype.NewMerger([]string{}).Merge(c)
+	left.Merge(c)
+	if render(c) != before {
+		t.Errorf("Merge modified its argument")
+	}
+}
+
+// TestMergerMerge_OptionalFields verifies fields missing from one of the merged trees
+// become optional, so -j gives the same presence as reading the inputs one by one
+func TestMergerMerge_OptionalFields(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	parsed := func(doc string) *jsontype.Merger {
+		m, err := jsontype.MergeStream(nil, "test", jsontype.NewJSONStream(strings.NewReader(doc)), jsontype.WithLogger(logger))
+		if err != nil {
+			t.Fatalf("merge %s: %v", doc, err)
+		}
+		return m
+	}
+
+	merged := parsed(`{"a": 1, "b": 2, "user": {"name": "n"}}`)
+	merged.Merge(parsed(`[{"a": 1, "c": 3, "user": {"name": "m", "email": "e"}}, {"a": 2}]`))
+
+	// the second tree is an array, its objects don't make fields of the first one optional
+	if b := merged.ChildrenMap["b"]; b.IsOptional() {
+		t.Errorf("$.b: expected required, got %d/%d", b.Occurrences, b.ParentOccurrences)
+	}
+
+	merged = parsed(`{"a": 1, "b": 2, "user": {"name": "n"}}`)
+	merged.Merge(parsed(`{"a": 1, "c": 3, "user": {"name": "m", "email": "e"}}`))
+	merged.Merge(parsed(`{"a": 2}`))
+
+	expect := map[string][2]int{
+		"a":          {3, 3},
+		"b":          {1, 3},
+		"c":          {1, 3},
+		"user":       {2, 3},
+		"user.name":  {2, 2},
+		"user.email": {1, 2},
+	}
+	for field, want := range expect {
+		m := merged
+		for key := range strings.SplitSeq(field, ".") {
+			m = m.ChildrenMap[key]
+		}
+		if got := [2]int{m.Occurrences, m.ParentOccurrences}; got != want {
+			t.Errorf("$.%s: got occurrences %v, want %v", field, got, want)
+		}
+	}
+}
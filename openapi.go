@@ -0,0 +1,439 @@
+package jsontype
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPIVersion is the version of the OpenAPI specification of generated documents
+const OpenAPIVersion = "3.1.0"
+
+// OpenAPIOptions configures GenerateOpenAPI
+type OpenAPIOptions struct {
+	// Title of the API (default "Inferred API")
+	Title string
+	// Version of the API (default "1.0.0")
+	Version string
+	// MediaType of request and response bodies (default "application/json")
+	MediaType string
+}
+
+// GenerateOpenAPI builds an OpenAPI 3.1 document describing bodies of every endpoint of the set.
+// Object shapes met more than once are moved into components/schemas and referenced with $ref.
+// The document is returned as a YAML node, see WriteOpenAPI
+func GenerateOpenAPI(set *EndpointSet, opts OpenAPIOptions) (*yaml.Node, error) {
+	if opts.Title == "" {
+		opts.Title = "Inferred API"
+	}
+	if opts.Version == "" {
+		opts.Version = "1.0.0"
+	}
+	if opts.MediaType == "" {
+		opts.MediaType = "application/json"
+	}
+
+	endpoints := set.Endpoints()
+	bodies := make([]*JSONSchema, len(endpoints))
+	for i, e := range endpoints {
+		bodies[i] = mergerToSchema(e.Merger)
+	}
+	h := newSchemaHoister(bodies)
+	for i, e := range endpoints {
+		bodies[i] = h.rewrite(bodies[i], endpointSchemaName(e.Key))
+	}
+
+	// endpoints are sorted by path and method, so operations are built one after another
+	paths := yamlMapping()
+	var pathItem, operation, responses *yaml.Node
+	for i, e := range endpoints {
+		newPath := i == 0 || e.Key.Path != endpoints[i-1].Key.Path
+		if newPath {
+			pathItem = yamlMapping()
+			yamlSet(paths, e.Key.Path, pathItem)
+		}
+		if newPath || e.Key.Method != endpoints[i-1].Key.Method {
+			operation = yamlMapping()
+			responses = nil
+			yamlSet(pathItem, strings.ToLower(e.Key.Method), operation)
+			if params := pathParameters(e.Key.Path); len(params.Content) > 0 {
+				yamlSet(operation, "parameters", params)
+			}
+		}
+
+		schema, err := schemaNode(bodies[i])
+		if err != nil {
+			return nil, err
+		}
+		content := yamlMapping(
+			"content", yamlMapping(
+				opts.MediaType, yamlMapping("schema", schema),
+			),
+		)
+		if e.Key.Direction == DirectionRequest {
+			yamlSet(operation, "requestBody", content)
+			continue
+		}
+		if responses == nil {
+			responses = yamlMapping()
+			yamlSet(operation, "responses", responses)
+		}
+		status, description := "default", "Response"
+		if e.Key.Status != 0 {
+			status = strconv.Itoa(e.Key.Status)
+			description = cmp.Or(http.StatusText(e.Key.Status), description)
+		}
+		response := yamlMapping("description", yamlString(description))
+		response.Content = append(response.Content, content.Content...)
+		yamlSet(responses, status, response)
+	}
+
+	doc := yamlMapping(
+		"openapi", yamlString(OpenAPIVersion),
+		"info", yamlMapping(
+			"title", yamlString(opts.Title),
+			"version", yamlString(opts.Version),
+		),
+		"paths", paths,
+	)
+	if len(h.names) > 0 {
+		schemas := yamlMapping()
+		for _, name := range h.names {
+			schema, err := schemaNode(h.schemas[name])
+			if err != nil {
+				return nil, err
+			}
+			yamlSet(schemas, name, schema)
+		}
+		yamlSet(doc, "components", yamlMapping("schemas", schemas))
+	}
+	return &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{doc}}, nil
+}
+
+// WriteOpenAPI writes the document generated by GenerateOpenAPI as YAML or, if asJSON is set, as JSON
+func WriteOpenAPI(set *EndpointSet, w io.Writer, asJSON bool, opts OpenAPIOptions) error {
+	doc, err := GenerateOpenAPI(set, opts)
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		var buf bytes.Buffer
+		if err := writeYAMLAsJSON(&buf, doc.Content[0]); err != nil {
+			return err
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+			return err
+		}
+		out.WriteByte('\n')
+		_, err = out.WriteTo(w)
+		return err
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+var rePathParam = regexp.MustCompile(`\{([^{}/]+)\}`)
+
+// pathParameters declares every {param} of the path template
+func pathParameters(path string) *yaml.Node {
+	params := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, match := range rePathParam.FindAllStringSubmatch(path, -1) {
+		params.Content = append(params.Content, yamlMapping(
+			"name", yamlString(match[1]),
+			"in", yamlString("path"),
+			"required", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "true"},
+			"schema", yamlMapping("type", yamlString("string")),
+		))
+	}
+	return params
+}
+
+// endpointSchemaName names a body schema after the endpoint: GET /users/{id} 200 -> GetUsersResponse
+func endpointSchemaName(key EndpointKey) string {
+	var sb strings.Builder
+	sb.WriteString(GoExportedName(strings.ToLower(key.Method)))
+	for _, segment := range strings.Split(key.Path, "/") {
+		if segment != "" && !rePathParam.MatchString(segment) {
+			sb.WriteString(GoExportedName(segment))
+		}
+	}
+	if key.Direction == DirectionRequest {
+		sb.WriteString("Request")
+	} else {
+		sb.WriteString("Response")
+	}
+	return sb.String()
+}
+
+// schemaHoister moves object schemas met more than once into named components
+type schemaHoister struct {
+	keys   map[*JSONSchema]string
+	counts map[string]int
+	// component name by shape key
+	byKey   map[string]string
+	schemas map[string]*JSONSchema
+	// component names in the order they were created
+	names []string
+	used  map[string]int
+}
+
+func newSchemaHoister(roots []*JSONSchema) *schemaHoister {
+	h := &schemaHoister{
+		keys:    make(map[*JSONSchema]string),
+		counts:  make(map[string]int),
+		byKey:   make(map[string]string),
+		schemas: make(map[string]*JSONSchema),
+		used:    make(map[string]int),
+	}
+
+	// shapes nested into a repeated shape are counted once per definition, not per use
+	naive := make(map[string]int)
+	for _, root := range roots {
+		h.count(root, naive, nil)
+	}
+	defined := make(map[string]bool)
+	for _, root := range roots {
+		h.count(root, h.counts, func(key string) bool {
+			if naive[key] < 2 {
+				return true
+			}
+			first := !defined[key]
+			defined[key] = true
+			return first
+		})
+	}
+	return h
+}
+
+// count counts object shapes of the schema, descend reports if children of the shape should be counted
+func (h *schemaHoister) count(s *JSONSchema, counts map[string]int, descend func(key string) bool) {
+	if s == nil {
+		return
+	}
+	if isHoistable(s) {
+		key := h.key(s)
+		counts[key]++
+		if descend != nil && !descend(key) {
+			return
+		}
+	}
+	for _, child := range schemaChildren(s) {
+		h.count(child, counts, descend)
+	}
+}
+
+// rewrite replaces repeated object shapes with references to components,
+// the hint names a component created for s
+func (h *schemaHoister) rewrite(s *JSONSchema, hint string) *JSONSchema {
+	if s == nil {
+		return nil
+	}
+	if isHoistable(s) && h.counts[h.key(s)] > 1 {
+		key := h.key(s)
+		name, exists := h.byKey[key]
+		if !exists {
+			name = h.reserveName(hint)
+			h.byKey[key] = name
+			h.names = append(h.names, name)
+			h.schemas[name] = h.rewriteChildren(s, hint)
+		}
+		return &JSONSchema{Ref: "#/components/schemas/" + name}
+	}
+	return h.rewriteChildren(s, hint)
+}
+
+func (h *schemaHoister) rewriteChildren(s *JSONSchema, hint string) *JSONSchema {
+	out := *s
+	out.Properties = nil
+	for _, prop := range s.Properties {
+		out.Properties = append(out.Properties, SchemaProperty{
+			Name:   prop.Name,
+			Schema: h.rewrite(prop.Schema, GoExportedName(prop.Name)),
+		})
+	}
+	out.Items = h.rewrite(s.Items, singularName(hint))
+	out.AdditionalProperties = h.rewrite(s.AdditionalProperties, hint+"Value")
+	out.PrefixItems = nil
+	for _, item := range s.PrefixItems {
+		out.PrefixItems = append(out.PrefixItems, h.rewrite(item, singularName(hint)))
+	}
+	out.AnyOf = nil
+	for _, branch := range s.AnyOf {
+		out.AnyOf = append(out.AnyOf, h.rewrite(branch, hint))
+	}
+	return &out
+}
+
+// singularName names an element of a list: Users -> User, Categories -> Category, Data -> DataItem
+func singularName(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 3:
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss") && len(name) > 1:
+		return strings.TrimSuffix(name, "s")
+	}
+	return name + "Item"
+}
+
+func (h *schemaHoister) reserveName(name string) string {
+	h.used[name]++
+	if n := h.used[name]; n > 1 {
+		return name + strconv.Itoa(n)
+	}
+	return name
+}
+
+// key identifies the shape of the schema regardless of the order of properties
+func (h *schemaHoister) key(s *JSONSchema) string {
+	if key, exists := h.keys[s]; exists {
+		return key
+	}
+	data, _ := json.Marshal(canonicalSchema(s))
+	h.keys[s] = string(data)
+	return string(data)
+}
+
+// canonicalSchema returns a copy of the schema with sorted properties
+func canonicalSchema(s *JSONSchema) *JSONSchema {
+	if s == nil {
+		return nil
+	}
+	out := *s
+	out.Properties = slices.Clone(s.Properties)
+	slices.SortFunc(out.Properties, func(a, b SchemaProperty) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	for i := range out.Properties {
+		out.Properties[i].Schema = canonicalSchema(out.Properties[i].Schema)
+	}
+	out.Required = slices.Sorted(slices.Values(s.Required))
+	out.Items = canonicalSchema(s.Items)
+	out.AdditionalProperties = canonicalSchema(s.AdditionalProperties)
+	out.PrefixItems = nil
+	for _, item := range s.PrefixItems {
+		out.PrefixItems = append(out.PrefixItems, canonicalSchema(item))
+	}
+	out.AnyOf = nil
+	for _, branch := range s.AnyOf {
+		out.AnyOf = append(out.AnyOf, canonicalSchema(branch))
+	}
+	return &out
+}
+
+// isHoistable reports if the schema is an object with known properties
+func isHoistable(s *JSONSchema) bool {
+	return slices.Contains(s.Type, "object") && len(s.Properties) > 0
+}
+
+func schemaChildren(s *JSONSchema) []*JSONSchema {
+	var children []*JSONSchema
+	for _, prop := range s.Properties {
+		children = append(children, prop.Schema)
+	}
+	children = append(children, s.Items, s.AdditionalProperties)
+	children = append(children, s.PrefixItems...)
+	children = append(children, s.AnyOf...)
+	return children
+}
+
+// ----- YAML nodes -----
+
+func yamlString(s string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}
+}
+
+// yamlMapping builds a mapping from key-value pairs
+func yamlMapping(pairs ...any) *yaml.Node {
+	m := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for i := 0; i < len(pairs); i += 2 {
+		yamlSet(m, pairs[i].(string), pairs[i+1].(*yaml.Node))
+	}
+	return m
+}
+
+func yamlSet(m *yaml.Node, key string, value *yaml.Node) {
+	m.Content = append(m.Content, yamlString(key), value)
+}
+
+// schemaNode converts the schema into a YAML node keeping the order of properties
+func schemaNode(s *JSONSchema) (*yaml.Node, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("marshal schema: %w", err)
+	}
+	// JSON is valid YAML, decoding it into a node keeps the order of keys
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("convert schema: %w", err)
+	}
+	node := doc.Content[0]
+	resetYAMLStyle(node)
+	return node, nil
+}
+
+// resetYAMLStyle drops the flow style of nodes decoded from JSON
+func resetYAMLStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, child := range n.Content {
+		resetYAMLStyle(child)
+	}
+}
+
+// writeYAMLAsJSON writes a node built from JSON-compatible values as compact JSON
+func writeYAMLAsJSON(w *bytes.Buffer, n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.MappingNode:
+		w.WriteByte('{')
+		for i := 0; i < len(n.Content); i += 2 {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			key, _ := json.Marshal(n.Content[i].Value)
+			w.Write(key)
+			w.WriteByte(':')
+			if err := writeYAMLAsJSON(w, n.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		w.WriteByte('}')
+	case yaml.SequenceNode:
+		w.WriteByte('[')
+		for i, item := range n.Content {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			if err := writeYAMLAsJSON(w, item); err != nil {
+				return err
+			}
+		}
+		w.WriteByte(']')
+	case yaml.ScalarNode:
+		switch n.ShortTag() {
+		case "!!str":
+			value, _ := json.Marshal(n.Value)
+			w.Write(value)
+		case "!!null":
+			w.WriteString("null")
+		default:
+			// booleans and numbers are written as is
+			w.WriteString(n.Value)
+		}
+	default:
+		return fmt.Errorf("unexpected YAML node kind %v", n.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package jsontype_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/4nd3r5on/jsontype"
+)
+
+func TestWriteOpenAPI(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	samples := []struct {
+		key  jsontype.EndpointKey
+		body string
+	}{
+		{jsontype.EndpointKey{Method: "GET", Path: "/users/{id}", Direction: jsontype.DirectionResponse, Status: 200},
+			`{"id": 1, "name": "alice", "address": {"city": "Berlin", "zip": "10115"}}`},
+		{jsontype.EndpointKey{Method: "GET", Path: "/users", Direction: jsontype.DirectionResponse, Status: 200},
+			`{"users": [{"id": 2, "name": "bob", "address": {"city": "Paris", "zip": "75001"}}]}`},
+		{jsontype.EndpointKey{Method: "POST", Path: "/users", Direction: jsontype.DirectionRequest},
+			`{"name": "carol", "address": {"zip": "1000", "city": "Vienna"}}`},
+		{jsontype.EndpointKey{Method: "GET", Path: "/users/{id}", Direction: jsontype.DirectionResponse, Status: 404},
+			`{"error": "not found"}`},
+	}
+	set := jsontype.NewEndpointSet()
+	for _, sample := range samples {
+		endpoint := set.Endpoint(sample.key)
+		_, err := jsontype.MergeStream(endpoint.Merger, "test",
+			jsontype.NewJSONStream(strings.NewReader(sample.body)), jsontype.WithLogger(logger))
+		if err != nil {
+			t.Fatalf("merge %s: %v", sample.key, err)
+		}
+		endpoint.Samples++
+	}
+
+	var buf bytes.Buffer
+	if err := jsontype.WriteOpenAPI(set, &buf, true, jsontype.OpenAPIOptions{Title: "Users"}); err != nil {
+		t.Fatalf("write openapi: %v", err)
+	}
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Title string `json:"title"`
+		} `json:"info"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if doc.OpenAPI != jsontype.OpenAPIVersion || doc.Info.Title != "Users" {
+		t.Errorf("unexpected header: %s %q", doc.OpenAPI, doc.Info.Title)
+	}
+	if _, exists := doc.Paths["/users/{id}"]["get"]; !exists {
+		t.Errorf("operation GET /users/{id} is missing")
+	}
+	if _, exists := doc.Paths["/users"]["post"]; !exists {
+		t.Errorf("operation POST /users is missing")
+	}
+
+	// the address is used by every user shape, users themselves are met twice
+	for _, name := range []string{"Address", "User"} {
+		if _, exists := doc.Components.Schemas[name]; !exists {
+			t.Errorf("schema %s isn't hoisted, got %v", name, buf.String())
+		}
+	}
+	if len(doc.Components.Schemas) != 2 {
+		t.Errorf("expected 2 component schemas, got %d", len(doc.Components.Schemas))
+	}
+	if n := strings.Count(buf.String(), `"$ref": "#/components/schemas/Address"`); n != 2 {
+		t.Errorf("expected the address to be referenced twice, got %d", n)
+	}
+
+	// YAML quotes status codes so they stay strings
+	buf.Reset()
+	if err := jsontype.WriteOpenAPI(set, &buf, false, jsontype.OpenAPIOptions{}); err != nil {
+		t.Fatalf("write openapi: %v", err)
+	}
+	for _, line := range []string{"openapi: 3.1.0", `"404":`, "$ref: '#/components/schemas/User'"} {
+		if !strings.Contains(buf.String(), line) {
+			t.Errorf("YAML doesn't contain %q:\n%s", line, buf.String())
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package jsontype
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+// ParseError tells where in the input parsing failed
+type ParseError struct {
+	// Label of the input, empty for Parse
+	Label string
+	// Path of the value being read
+	Path []string
+	// Offset of the offending byte, -1 if the stream doesn't report offsets
+	Offset int64
+	// Line and Column (in bytes) of the offending byte, starting at 1, zero if unknown
+	Line   int
+	Column int
+	// Snippet is the offending line with a caret under the column, empty if unknown
+	Snippet string
+	Cause   error
+}
+
+func (e *ParseError) Error() string {
+	var sb strings.Builder
+	if e.Label != "" {
+		sb.WriteString(e.Label + ": ")
+	}
+	switch {
+	case e.Line > 0:
+		fmt.Fprintf(&sb, "line %d, column %d (offset %d): ", e.Line, e.Column, e.Offset)
+	case e.Offset >= 0:
+		fmt.Fprintf(&sb, "offset %d: ", e.Offset)
+	}
+	sb.WriteString(e.Cause.Error())
+	return sb.String()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Cause
+}
+
+// OffsetReporter is implemented by streams knowing the input offset,
+// e.g. DefaultStream through json.Decoder
+type OffsetReporter interface {
+	// InputOffset returns the offset following the last token
+	InputOffset() int64
+}
+
+// lineLocator is implemented by streams of text formats tracking lines of the input
+type lineLocator interface {
+	locate(offset int64) (line, column int, snippet string)
+}
+
+// parseError wraps err with the position of the stream
+func (p *parser) parseError(s Stream, err error) error {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return err
+	}
+	pe := &ParseError{Path: slices.Clone(p.path), Offset: -1, Cause: err}
+	if r, ok := s.(OffsetReporter); ok {
+		pe.Offset = r.InputOffset()
+	}
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// the offending byte is the last one read
+		pe.Offset = max(syntaxErr.Offset-1, 0)
+	}
+	if l, ok := s.(lineLocator); ok && pe.Offset >= 0 {
+		pe.Line, pe.Column, pe.Snippet = l.locate(pe.Offset)
+	}
+	return pe
+}
+
+// lineTrackerWindow is how much of the recent input is kept for snippets
+const lineTrackerWindow = 64 << 10
+
+// lineTracker counts lines of the input and keeps its recent part,
+// so positions near the read offset can be turned into lines and columns
+type lineTracker struct {
+	// recent input starting at offset start
+	tail  []byte
+	start int64
+	// newlines before start
+	lines int
+	// offset of the last newline before start, -1 if none
+	lastNewline int64
+}
+
+func newLineTracker() *lineTracker {
+	return &lineTracker{lastNewline: -1}
+}
+
+func (t *lineTracker) write(b []byte) {
+	t.tail = append(t.tail, b...)
+	// drop old input in large steps to keep copying cheap
+	excess := len(t.tail) - lineTrackerWindow
+	if excess < lineTrackerWindow {
+		return
+	}
+	dropped := t.tail[:excess]
+	t.lines += bytes.Count(dropped, []byte{'\n'})
+	if i := bytes.LastIndexByte(dropped, '\n'); i >= 0 {
+		t.lastNewline = t.start + int64(i)
+	}
+	t.tail = append(t.tail[:0], t.tail[excess:]...)
+	t.start += int64(excess)
+}
+
+// locate returns the line and the column of the offset, zero if it's out of the window
+func (t *lineTracker) locate(offset int64) (line, column int, snippet string) {
+	if offset < t.start || offset > t.start+int64(len(t.tail)) {
+		return 0, 0, ""
+	}
+	before := t.tail[:offset-t.start]
+	line = t.lines + bytes.Count(before, []byte{'\n'}) + 1
+	lineStart := t.lastNewline + 1
+	if i := bytes.LastIndexByte(before, '\n'); i >= 0 {
+		lineStart = t.start + int64(i) + 1
+	}
+	column = int(offset-lineStart) + 1
+
+	// the line may have started before the window
+	if lineStart >= t.start {
+		text := t.tail[lineStart-t.start:]
+		if i := bytes.IndexByte(text, '\n'); i >= 0 {
+			text = text[:i]
+		}
+		snippet = lineSnippet(line, bytes.TrimSuffix(text, []byte{'\r'}), column-1)
+	}
+	return line, column, snippet
+}
+
+// snippetWidth bounds the part of a long line shown in snippets
+const snippetWidth = 120
+
+// lineSnippet renders the line with a caret under the byte at col
+func lineSnippet(line int, text []byte, col int) string {
+	col = min(col, len(text))
+	from, to := 0, len(text)
+	if len(text) > snippetWidth {
+		from = max(col-snippetWidth/2, 0)
+		to = min(from+snippetWidth, len(text))
+		for from > 0 && !utf8.RuneStart(text[from]) {
+			from--
+		}
+		for to < len(text) && !utf8.RuneStart(text[to]) {
+			to++
+		}
+	}
+
+	shown := string(text[from:to])
+	caret := text[from:col]
+	if from > 0 {
+		shown = "..." + shown
+		caret = append([]byte("..."), caret...)
+	}
+	if to < len(text) {
+		shown += "..."
+	}
+
+	// keep tabs so the caret lines up
+	var pad strings.Builder
+	for _, r := range string(caret) {
+		if r == '\t' {
+			pad.WriteRune('\t')
+		} else {
+			pad.WriteRune(' ')
+		}
+	}
+
+	number := fmt.Sprint(line)
+	return fmt.Sprintf("%s | %s\n%s | %s^", number, shown, strings.Repeat(" ", len(number)), pad.String())
+}
+
+// InputError is an input that failed to parse
+type InputError struct {
+	Label string
+	Err   error
+}
+
+// InputErrors collects errors of failed inputs, so reading many inputs can go on
+// past the broken ones. It is safe for concurrent use
+type InputErrors struct {
+	mu     sync.Mutex
+	errors []InputError
+}
+
+func (e *InputErrors) Add(label string, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.errors = append(e.errors, InputError{Label: label, Err: err})
+}
+
+func (e *InputErrors) Len() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.errors)
+}
+
+// Errors returns the collected errors sorted by label
+func (e *InputErrors) Errors() []InputError {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	out := slices.Clone(e.errors)
+	slices.SortStableFunc(out, func(a, b InputError) int {
+		return strings.Compare(a.Label, b.Label)
+	})
+	return out
+}
+
+// WriteSummary writes a table of failed inputs with positions of parse errors
+func (e *InputErrors) WriteSummary(w io.Writer) error {
+	errs := e.Errors()
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "failed inputs: %d\n", len(errs))
+	fmt.Fprintln(tw, "INPUT\tPOSITION\tERROR")
+	for _, inputErr := range errs {
+		position, cause := "-", inputErr.Err
+		var parseErr *ParseError
+		if errors.As(inputErr.Err, &parseErr) {
+			cause = parseErr.Cause
+			switch {
+			case parseErr.Line > 0:
+				position = fmt.Sprintf("%d:%d", parseErr.Line, parseErr.Column)
+			case parseErr.Offset >= 0:
+				position = fmt.Sprintf("offset %d", parseErr.Offset)
+			}
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%v\n", inputErr.Label, position, cause)
+	}
+	return tw.Flush()
+}
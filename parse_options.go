@@ -0,0 +1,188 @@
+package jsontype
+
+import (
+	"context"
+	"log/slog"
+)
+
+// ParseOptions configures Parse.
+// The zero value parses everything with the default string detectors
+type ParseOptions struct {
+	// ParseObjects limits parsing to the listed paths (and everything on the way to them)
+	ParseObjects [][]string
+	// IgnoreObjects skips the listed paths and everything below them, never the root
+	IgnoreObjects [][]string
+	// MaxDepth skips values nested deeper than the limit (0 = unlimited)
+	MaxDepth int
+
+	// Detectors analyze strings, nil means DefaultDetectorSet
+	Detectors *DetectorSet
+	// NoStringAnalysis records every string as TypeString
+	NoStringAnalysis bool
+	// NumberAnalysis detects integers that look like unix timestamps
+	NumberAnalysis bool
+
+	// NDJSON parses every top-level value of the stream as an element of a virtual root array.
+	// Path filters and depth limit are applied relative to each record
+	NDJSON bool
+	// Concatenated reads top-level values until the end of the stream, e.g. `{...}{...}[...]`,
+	// every value is parsed as a separate document at the root. Can't be combined with NDJSON
+	Concatenated bool
+	// DocumentLabels makes MergeStream label every concatenated value as "label#N", N starting at 0,
+	// otherwise all of them are merged under the label
+	DocumentLabels bool
+
+	// MaxTupleLength is used by MergeStream: arrays (and objects with integer keys)
+	// with more indices are always collapsed, even if their elements differ.
+	// Defaults to DefaultMaxTupleLength
+	MaxTupleLength int
+
+	// Partial keeps values parsed before an error: MergeStream merges them
+	// and Parse returns the tree built so far, both along with the error
+	Partial bool
+
+	// Context aborts parsing once done, checked between tokens
+	Context context.Context
+	Limits  ParseLimits
+
+	Hooks  ParseHooks
+	Logger *slog.Logger
+}
+
+// ParseHooks are called while parsing, nil hooks are ignored
+type ParseHooks struct {
+	// OnValue is called for every parsed value, returning an error aborts parsing
+	OnValue func(path []string, t DetectedType) error
+	// OnSkip is called for every value skipped by path filters or depth limit
+	OnSkip func(path []string)
+}
+
+// ParseOption modifies ParseOptions
+type ParseOption func(*ParseOptions)
+
+// NewParseOptions applies options on top of the defaults
+func NewParseOptions(opts ...ParseOption) ParseOptions {
+	var o ParseOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithParseObjects limits parsing to the paths
+func WithParseObjects(paths ...[]string) ParseOption {
+	return func(o *ParseOptions) {
+		o.ParseObjects = append(o.ParseObjects, paths...)
+	}
+}
+
+// WithIgnoreObjects skips the paths
+func WithIgnoreObjects(paths ...[]string) ParseOption {
+	return func(o *ParseOptions) {
+		o.IgnoreObjects = append(o.IgnoreObjects, paths...)
+	}
+}
+
+// WithMaxDepth limits the depth of parsed values (0 = unlimited)
+func WithMaxDepth(depth int) ParseOption {
+	return func(o *ParseOptions) {
+		o.MaxDepth = depth
+	}
+}
+
+// WithDetectors analyzes strings with the set, nil disables string analysis
+func WithDetectors(detectors *DetectorSet) ParseOption {
+	return func(o *ParseOptions) {
+		o.Detectors = detectors
+		o.NoStringAnalysis = detectors == nil
+	}
+}
+
+// WithoutStringAnalysis records every string as TypeString
+func WithoutStringAnalysis() ParseOption {
+	return func(o *ParseOptions) {
+		o.NoStringAnalysis = true
+	}
+}
+
+// WithNumberAnalysis detects integers that look like unix timestamps
+func WithNumberAnalysis() ParseOption {
+	return func(o *ParseOptions) {
+		o.NumberAnalysis = true
+	}
+}
+
+// WithNDJSON parses the stream as newline-delimited JSON
+func WithNDJSON() ParseOption {
+	return func(o *ParseOptions) {
+		o.NDJSON = true
+	}
+}
+
+// WithConcatenated reads every top-level value of the stream as a separate document
+func WithConcatenated() ParseOption {
+	return func(o *ParseOptions) {
+		o.Concatenated = true
+	}
+}
+
+// WithDocumentLabels labels concatenated values as "label#N" in MergeStream
+func WithDocumentLabels() ParseOption {
+	return func(o *ParseOptions) {
+		o.Concatenated = true
+		o.DocumentLabels = true
+	}
+}
+
+// WithMaxTupleLength sets the longest array MergeStream may keep as a tuple
+func WithMaxTupleLength(n int) ParseOption {
+	return func(o *ParseOptions) {
+		o.MaxTupleLength = n
+	}
+}
+
+// WithPartial keeps values parsed before an error
+func WithPartial() ParseOption {
+	return func(o *ParseOptions) {
+		o.Partial = true
+	}
+}
+
+// WithContext aborts parsing when the context is done
+func WithContext(ctx context.Context) ParseOption {
+	return func(o *ParseOptions) {
+		o.Context = ctx
+	}
+}
+
+// WithLimits bounds resources spent on the input
+func WithLimits(limits ParseLimits) ParseOption {
+	return func(o *ParseOptions) {
+		o.Limits = limits
+	}
+}
+
+// WithHooks sets parsing hooks
+func WithHooks(hooks ParseHooks) ParseOption {
+	return func(o *ParseOptions) {
+		o.Hooks = hooks
+	}
+}
+
+// WithLogger sets the logger, slog.Default is used otherwise
+func WithLogger(logger *slog.Logger) ParseOption {
+	return func(o *ParseOptions) {
+		o.Logger = logger
+	}
+}
+
+// detectors returns the string detectors to use, nil if string analysis is disabled
+func (o *ParseOptions) detectors() *DetectorSet {
+	switch {
+	case o.NoStringAnalysis:
+		return nil
+	case o.Detectors == nil:
+		return defaultDetectors
+	}
+	return o.Detectors
+}
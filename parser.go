@@ -1,18 +1,130 @@
 package jsontype
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strconv"
 )
 
+// valueSink receives parsed values in document order.
+// Containers are entered before their children are recorded and left after them.
+// decimal is only set for numbers written with a fraction or an exponent,
+// intType only for numbers detected as unix timestamps (the integer type they were read as)
+type valueSink interface {
+	value(path []string, t DetectedType, decimal DecimalInfo, intType DetectedType)
+	enter(path []string, t DetectedType)
+	leave()
+	// endDocument is called after every top-level value of a concatenated stream
+	endDocument()
+}
+
 type parser struct {
-	Root             *FieldInfo
-	seenPaths        map[string]*FieldInfo
-	noStringAnalysis bool
-	logger           *slog.Logger
+	sink           valueSink
+	parseObjects   [][]string
+	ignoreObjects  [][]string
+	maxDepth       int
+	detectors      *DetectorSet
+	numberAnalysis bool
+	hooks          ParseHooks
+	logger         *slog.Logger
+	ctx            context.Context
+	limits         ParseLimits
+	tokens         int64
+	// tokens read by skipValue, they don't count against MaxTokens
+	skipped int64
+	// path of the value being read, for errors
+	path []string
+	// number of leading path segments ignored by filters and depth limit
+	// (1 for records of a virtual root array)
+	pathOffset int
+}
+
+func newParser(o ParseOptions) *parser {
+	logger := o.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	ctx := o.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &parser{
+		parseObjects:   o.ParseObjects,
+		ignoreObjects:  o.IgnoreObjects,
+		maxDepth:       o.MaxDepth,
+		detectors:      o.detectors(),
+		numberAnalysis: o.NumberAnalysis,
+		hooks:          o.Hooks,
+		logger:         logger,
+		ctx:            ctx,
+		limits:         o.Limits,
+	}
+}
+
+// Parse parses a single JSON value from the stream (or every record with WithNDJSON)
+func Parse(s Stream, opts ...ParseOption) (*FieldInfo, error) {
+	return ParseWithOptions(s, NewParseOptions(opts...))
+}
+
+// ParseWithOptions is Parse taking options as a struct
+func ParseWithOptions(s Stream, o ParseOptions) (*FieldInfo, error) {
+	tree := &treeSink{}
+	err := parseInto(tree, s, o)
+	if err != nil && !o.Partial {
+		return nil, err
+	}
+	return tree.root, err
+}
+
+// ParseDocuments parses every top-level value of a concatenated stream (`{...}{...}[...]`)
+// into its own tree
+func ParseDocuments(s Stream, opts ...ParseOption) ([]*FieldInfo, error) {
+	o := NewParseOptions(opts...)
+	o.Concatenated = true
+	tree := &treeSink{}
+	err := parseInto(tree, s, o)
+	if err != nil && !o.Partial {
+		return nil, err
+	}
+	if err != nil && tree.root != nil {
+		// the prefix of the failing document
+		tree.endDocument()
+	}
+	return tree.documents, err
+}
+
+// parseInto feeds values of the stream into the sink
+func parseInto(sink valueSink, s Stream, o ParseOptions) error {
+	p := newParser(o)
+	p.sink = sink
+	if o.Limits.MaxBytes > 0 {
+		limiter, ok := s.(ReadLimiter)
+		if !ok {
+			return fmt.Errorf("stream %T doesn't support the bytes limit", s)
+		}
+		limiter.SetReadLimit(o.Limits.MaxBytes)
+	}
+	var err error
+	switch {
+	case o.NDJSON && o.Concatenated:
+		return fmt.Errorf("NDJSON and concatenated streams can't be combined")
+	case o.NDJSON:
+		err = p.parseRecords(s)
+	case o.Concatenated:
+		err = p.parseDocuments(s)
+	default:
+		err = p.parseValue(s)
+	}
+	if err != nil {
+		return p.parseError(s, err)
+	}
+	return nil
 }
 
+// ParseStream parses a single JSON value from the stream
 func ParseStream(
 	s Stream,
 	parseObjects, ignoreObjects [][]string,
@@ -20,35 +132,91 @@ func ParseStream(
 	noStringAnalysis bool,
 	logger *slog.Logger,
 ) (root *FieldInfo, err error) {
-	if logger == nil {
-		logger = slog.Default()
-	}
+	return ParseWithOptions(s, legacyParseOptions(parseObjects, ignoreObjects, maxDepth, noStringAnalysis, logger))
+}
 
-	p := parser{
-		seenPaths:        make(map[string]*FieldInfo),
-		noStringAnalysis: noStringAnalysis,
-		logger:           logger,
+// ParseNDJSON parses every top-level value of the stream (newline-delimited JSON / JSON Lines)
+// as an element of a virtual root array, so the merged result describes a single record.
+// Path filters and depth limit are applied relative to each record
+func ParseNDJSON(
+	s Stream,
+	parseObjects, ignoreObjects [][]string,
+	maxDepth int,
+	noStringAnalysis bool,
+	logger *slog.Logger,
+) (root *FieldInfo, err error) {
+	o := legacyParseOptions(parseObjects, ignoreObjects, maxDepth, noStringAnalysis, logger)
+	o.NDJSON = true
+	return ParseWithOptions(s, o)
+}
+
+func legacyParseOptions(
+	parseObjects, ignoreObjects [][]string,
+	maxDepth int,
+	noStringAnalysis bool,
+	logger *slog.Logger,
+) ParseOptions {
+	return ParseOptions{
+		ParseObjects:     parseObjects,
+		IgnoreObjects:    ignoreObjects,
+		MaxDepth:         maxDepth,
+		NoStringAnalysis: noStringAnalysis,
+		Logger:           logger,
 	}
+}
 
+func (p *parser) parseValue(s Stream) error {
 	p.logger.Info("starting JSON stream parsing",
-		"maxDepth", maxDepth,
-		"parseObjectsCount", len(parseObjects),
-		"ignoreObjectsCount", len(ignoreObjects))
+		"maxDepth", p.maxDepth,
+		"parseObjectsCount", len(p.parseObjects),
+		"ignoreObjectsCount", len(p.ignoreObjects))
 
 	// depth = 0, current path = [], current key = ""
-	err = p.getParseToken(
-		s,
-		parseObjects, ignoreObjects,
-		maxDepth,
-		[]string{},
-		nil,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse JSON stream: %w", err)
+	if err := p.getParseToken(s, []string{}); err != nil {
+		return fmt.Errorf("failed to parse JSON stream: %w", err)
 	}
 
 	p.logger.Info("successfully completed JSON stream parsing")
-	return p.Root, nil
+	return nil
+}
+
+func (p *parser) parseRecords(s Stream) error {
+	p.pathOffset = 1
+
+	p.logger.Info("starting NDJSON stream parsing",
+		"maxDepth", p.maxDepth,
+		"parseObjectsCount", len(p.parseObjects),
+		"ignoreObjectsCount", len(p.ignoreObjects))
+
+	p.sink.enter([]string{}, TypeArray)
+	var i int
+	for ; s.More(); i++ {
+		if err := p.getParseToken(s, []string{strconv.Itoa(i)}); err != nil {
+			return fmt.Errorf("failed to parse NDJSON record %d: %w", i, err)
+		}
+	}
+	p.sink.leave()
+
+	p.logger.Info("successfully completed NDJSON stream parsing", "records", i)
+	return nil
+}
+
+func (p *parser) parseDocuments(s Stream) error {
+	p.logger.Info("starting concatenated JSON stream parsing",
+		"maxDepth", p.maxDepth,
+		"parseObjectsCount", len(p.parseObjects),
+		"ignoreObjectsCount", len(p.ignoreObjects))
+
+	var i int
+	for ; s.More(); i++ {
+		if err := p.getParseToken(s, []string{}); err != nil {
+			return fmt.Errorf("failed to parse document %d: %w", i, err)
+		}
+		p.sink.endDocument()
+	}
+
+	p.logger.Info("successfully completed concatenated JSON stream parsing", "documents", i)
+	return nil
 }
 
 // Use this function when previous token is already parsed
@@ -56,16 +224,15 @@ func ParseStream(
 //	like for example key in an object is already read for path and we need to read the value
 func (p *parser) getParseToken(
 	s Stream,
-	parseObjects, ignoreObjects [][]string,
-	maxDepth int,
 	currentPath []string,
-	parent *FieldInfo,
 ) error {
 	pathStr := PathToString(currentPath)
 
-	if !p.shouldParse(parseObjects, ignoreObjects, maxDepth, currentPath) {
+	if !p.shouldParse(currentPath) {
 		p.logger.Debug("skipping value at path", "path", pathStr)
-		err := s.SkipValue()
+		p.onSkip(currentPath)
+		p.path = currentPath
+		err := p.skipValue(s)
 		if err != nil {
 			return fmt.Errorf("failed to skip value by path %s: %w", pathStr, err)
 		}
@@ -73,22 +240,19 @@ func (p *parser) getParseToken(
 	}
 
 	p.logger.Debug("reading token", "path", pathStr)
-	token, err := s.Token()
+	token, err := p.nextToken(s, currentPath)
 	if err != nil {
 		return fmt.Errorf("failed to read token by path %s: %w", pathStr, err)
 	}
 
-	return p.parseToken(s, token, parseObjects, ignoreObjects, maxDepth, currentPath, parent)
+	return p.parseToken(s, token, currentPath)
 }
 
 // Call this function when
 func (p *parser) parseToken(
 	s Stream,
 	token json.Token,
-	parseObjects, ignoreObjects [][]string,
-	maxDepth int,
 	currentPath []string,
-	parent *FieldInfo,
 ) error {
 	pathStr := PathToString(currentPath)
 
@@ -97,61 +261,63 @@ func (p *parser) parseToken(
 		switch t {
 		case '{':
 			p.logger.Debug("parsing object", "path", pathStr)
-			return p.parseObject(s, parseObjects, ignoreObjects, maxDepth, currentPath, parent)
+			return p.parseObject(s, currentPath)
 		case '[':
 			p.logger.Debug("parsing array", "path", pathStr)
-			return p.parseArray(s, parseObjects, ignoreObjects, maxDepth, currentPath, parent)
+			return p.parseArray(s, currentPath)
 		}
 	case nil:
 		p.logger.Debug("detected null", "path", pathStr)
-		p.recordType(parent, currentPath, TypeNull) // null
+		return p.recordValue(currentPath, TypeNull) // null
 	case bool:
 		p.logger.Debug("detected bool", "path", pathStr, "value", t)
-		p.recordType(parent, currentPath, TypeBool)
+		return p.recordValue(currentPath, TypeBool)
 	case float64:
 		// Determine if it's int32, int64, or float64
-		detectedType := detectNumberType(t)
+		intType := detectNumberType(t)
+		detectedType := p.analyzeNumber(intType, t)
 		p.logger.Debug("detected number", "path", pathStr, "type", detectedType, "value", t)
-		p.recordType(parent, currentPath, detectedType)
+		return p.recordNumber(currentPath, detectedType, DecimalInfo{}, intType)
 	case json.Number:
-		detectedType := detectNumberTypeFromString(string(t))
+		intType, decimal := DetectNumberLiteral(string(t))
+		detectedType := intType
+		if f, err := t.Float64(); err == nil {
+			detectedType = p.analyzeNumber(intType, f)
+		}
 		p.logger.Debug("detected number (json.Number)", "path", pathStr, "type", detectedType, "value", t)
-		p.recordType(parent, currentPath, detectedType)
+		return p.recordNumber(currentPath, detectedType, decimal, intType)
 	case string:
-		var detectedType DetectedType
-		if p.noStringAnalysis {
-			detectedType = TypeString
-		} else {
-			detectedType = DetectStrType(t)
-		}
+		// nil detector set means no string analysis
+		detectedType := p.detectors.Detect(t)
 		p.logger.Debug("detected string", "path", pathStr, "length", len(t))
-		p.recordType(parent, currentPath, detectedType)
+		return p.recordValue(currentPath, detectedType)
+	case TypedValue:
+		p.logger.Debug("detected typed value", "path", pathStr, "type", t.Type)
+		return p.recordValue(currentPath, t.Type)
 	}
 	return nil
 }
 
 func (p *parser) parseObject(
 	s Stream,
-	parseObjects, ignoreObjects [][]string,
-	maxDepth int,
 	objPath []string,
-	parent *FieldInfo,
 ) error {
 	pathStr := PathToString(objPath)
 	p.logger.Debug("entering object", "path", pathStr)
+	if err := p.checkNesting(objPath); err != nil {
+		return err
+	}
 
-	var objItem *FieldInfo
 	objType := TypeObj
 
 	// Used for predicting object type + early exit if first token is delim
-	firstToken, err := s.Token()
+	firstToken, err := p.nextToken(s, objPath)
 	if err != nil {
 		return fmt.Errorf("failed to read first token in object by path %s: %w", pathStr, err)
 	}
 	if IsDelim(firstToken, '}') {
 		p.logger.Debug("empty object detected", "path", pathStr)
-		p.recordType(parent, objPath, objType)
-		return nil
+		return p.recordValue(objPath, objType)
 	}
 	firstKey, isKey := firstToken.(string)
 	if !isKey {
@@ -163,25 +329,23 @@ func (p *parser) parseObject(
 		objType = TypeObjInt
 	}
 
-	objItem = p.recordType(parent, objPath, objType)
-	err = p.getParseToken(
-		s, parseObjects, ignoreObjects,
-		maxDepth,
-		append(objPath, firstKey),
-		objItem,
-	)
+	if err := p.enterContainer(objPath, objType); err != nil {
+		return err
+	}
+	err = p.getParseToken(s, append(objPath, firstKey))
 	if err != nil {
 		return err
 	}
 
 	var i int
 	for {
-		token, err := s.Token()
+		token, err := p.nextToken(s, objPath)
 		if err != nil {
 			return fmt.Errorf("failed to read token in object path %s on iteration %d: %w", pathStr, i, err)
 		}
 		if IsDelim(token, '}') {
 			p.logger.Debug("closing object", "path", pathStr, "totalKeys", i+1)
+			p.sink.leave()
 			return nil
 		}
 
@@ -189,9 +353,13 @@ func (p *parser) parseObject(
 		if !isKey {
 			return fmt.Errorf("failed to parse object %s on iteration %d: expected key (string) or '}' as a token, got: %v", pathStr, i, token)
 		}
+		// the first key is already parsed
+		if p.limits.MaxObjectKeys > 0 && i+2 > p.limits.MaxObjectKeys {
+			return p.limitError(LimitObjectKeys, int64(p.limits.MaxObjectKeys), objPath)
+		}
 
 		iterationPath := append(objPath, key)
-		err = p.getParseToken(s, parseObjects, ignoreObjects, maxDepth, iterationPath, objItem)
+		err = p.getParseToken(s, iterationPath)
 		if err != nil {
 			return err
 		}
@@ -202,21 +370,36 @@ func (p *parser) parseObject(
 
 func (p *parser) parseArray(
 	s Stream,
-	parseObjects, ignoreObjects [][]string,
-	maxDepth int,
 	arrayPath []string,
-	parent *FieldInfo,
 ) error {
 	pathStr := PathToString(arrayPath)
 	p.logger.Debug("entering array", "path", pathStr)
+	if err := p.checkNesting(arrayPath); err != nil {
+		return err
+	}
 
-	arrayItem := p.recordType(parent, arrayPath, TypeArray)
+	if err := p.enterContainer(arrayPath, TypeArray); err != nil {
+		return err
+	}
 	var i int
 	for {
-		iterationPath := append(arrayPath, fmt.Sprintf("%d", i))
-		if !p.shouldParse(parseObjects, ignoreObjects, maxDepth, iterationPath) {
+		// Parsing children until the closing array delim
+		if !s.More() {
+			if _, err := p.nextToken(s, arrayPath); err != nil {
+				return fmt.Errorf("failed to read closing token of array %s: %w", pathStr, err)
+			}
+			p.logger.Debug("closing array", "path", pathStr, "totalElements", i)
+			p.sink.leave()
+			return nil
+		}
+
+		iterationPath := append(arrayPath, strconv.Itoa(i))
+		tooMany := p.limits.MaxArrayElements > 0 && i >= p.limits.MaxArrayElements
+		if tooMany || !p.shouldParse(iterationPath) {
 			p.logger.Debug("skipping array element", "path", PathToString(iterationPath))
-			err := s.SkipValue()
+			p.onSkip(iterationPath)
+			p.path = iterationPath
+			err := p.skipValue(s)
 			if err != nil {
 				return fmt.Errorf("failed to skip value by path %s iteration %d: %w", pathStr, i, err)
 			}
@@ -224,17 +407,12 @@ func (p *parser) parseArray(
 			continue
 		}
 
-		token, err := s.Token()
+		token, err := p.nextToken(s, iterationPath)
 		if err != nil {
 			return fmt.Errorf("failed to read token in array %s iteration %d: %w", pathStr, i, err)
 		}
-		// Parsing children until the closing array delim
-		if IsDelim(token, ']') {
-			p.logger.Debug("closing array", "path", pathStr, "totalElements", i)
-			return nil
-		}
 		// Parsing whatever was on that token (maybe delim for opening some other object, maybe some primitive value)
-		err = p.parseToken(s, token, parseObjects, ignoreObjects, maxDepth, iterationPath, arrayItem)
+		err = p.parseToken(s, token, iterationPath)
 		if err != nil {
 			return err
 		}
@@ -242,48 +420,135 @@ func (p *parser) parseArray(
 	}
 }
 
-func (p *parser) recordType(
-	parent *FieldInfo,
-	currentPath []string,
-	detectedType DetectedType,
-) *FieldInfo {
-	pathStr := PathToString(currentPath)
-	pathCopy := make([]string, len(currentPath))
-	copy(pathCopy, currentPath)
+// ctxCheckInterval is how often (in tokens) the context is checked
+const ctxCheckInterval = 64
 
-	item := &FieldInfo{
-		Parent:      parent,
-		Path:        pathCopy,
-		Type:        detectedType,
-		Children:    make([]*FieldInfo, 0),
-		ChildrenMap: make(map[string]*FieldInfo, 0),
+// nextToken reads a token, enforcing limits and the context
+func (p *parser) nextToken(s Stream, currentPath []string) (json.Token, error) {
+	p.path = currentPath
+	if p.tokens%ctxCheckInterval == 0 {
+		if err := p.ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	p.tokens++
+	if p.limits.MaxTokens > 0 && p.tokens > p.limits.MaxTokens {
+		return nil, p.limitError(LimitTokens, p.limits.MaxTokens, currentPath)
 	}
 
-	if parent != nil {
-		idx := currentPath[len(currentPath)-1]
-		parent.Children = append(parent.Children, item)
-		parent.ChildrenMap[idx] = item
-		p.logger.Debug("recorded field type", "path", pathStr, "type", detectedType, "parentPath", PathToString(parent.Path))
-	} else {
-		p.Root = item
-		p.logger.Debug("recorded root type", "type", detectedType)
+	token, err := s.Token()
+	if err != nil {
+		return nil, err
 	}
+	if str, isStr := token.(string); isStr && p.limits.MaxStringLength > 0 && len(str) > p.limits.MaxStringLength {
+		return nil, p.limitError(LimitStringLength, int64(p.limits.MaxStringLength), currentPath)
+	}
+	return token, nil
+}
 
-	return item
+// skipValue reads the tokens of the next value, including nested ones.
+// Unlike Stream.SkipValue it checks the context on the way, so skipping
+// a huge value can be cancelled
+func (p *parser) skipValue(s Stream) error {
+	depth := 0
+	for {
+		p.skipped++
+		if p.skipped%ctxCheckInterval == 0 {
+			if err := p.ctx.Err(); err != nil {
+				return err
+			}
+		}
+		token, err := s.Token()
+		if err != nil {
+			return err
+		}
+		if delim, isDelim := token.(json.Delim); isDelim {
+			switch delim {
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
 }
 
-func (p *parser) shouldParse(
-	parseObjects, ignoreObjects [][]string,
-	maxDepth int,
-	currentPath []string,
-) bool {
-	if maxDepth > 0 && len(currentPath) > maxDepth {
+// checkNesting fails if a container at the path is nested too deep
+func (p *parser) checkNesting(containerPath []string) error {
+	nesting := len(containerPath) - min(p.pathOffset, len(containerPath)) + 1
+	if p.limits.MaxNesting > 0 && nesting > p.limits.MaxNesting {
+		return p.limitError(LimitNesting, int64(p.limits.MaxNesting), containerPath)
+	}
+	return nil
+}
+
+func (p *parser) limitError(limit LimitKind, maxValue int64, currentPath []string) error {
+	return &LimitError{Limit: limit, Max: maxValue, Path: slices.Clone(currentPath)}
+}
+
+// analyzeNumber refines integer types with their semantics if number analysis is enabled
+func (p *parser) analyzeNumber(detectedType DetectedType, f float64) DetectedType {
+	if !p.numberAnalysis || !IsIntegerType(detectedType) {
+		return detectedType
+	}
+	if epochType, ok := DetectEpochType(f); ok {
+		return epochType
+	}
+	return detectedType
+}
+
+// recordValue records a primitive (or empty object) value and reports it to the OnValue hook
+func (p *parser) recordValue(currentPath []string, detectedType DetectedType) error {
+	return p.recordNumber(currentPath, detectedType, DecimalInfo{}, "")
+}
+
+// recordNumber is recordValue keeping the digits of decimal numbers and
+// the integer type of numbers refined by analyzeNumber
+func (p *parser) recordNumber(currentPath []string, detectedType DetectedType, decimal DecimalInfo, intType DetectedType) error {
+	p.logger.Debug("recorded value type", "path", PathToString(currentPath), "type", detectedType)
+	if !IsEpochType(detectedType) {
+		intType = ""
+	}
+	p.sink.value(currentPath, detectedType, decimal, intType)
+	return p.onValue(currentPath, detectedType)
+}
+
+// enterContainer records a container value, values recorded next are its children
+// until the sink leaves it
+func (p *parser) enterContainer(currentPath []string, detectedType DetectedType) error {
+	p.logger.Debug("recorded container type", "path", PathToString(currentPath), "type", detectedType)
+	p.sink.enter(currentPath, detectedType)
+	return p.onValue(currentPath, detectedType)
+}
+
+func (p *parser) onValue(currentPath []string, detectedType DetectedType) error {
+	if p.hooks.OnValue == nil {
+		return nil
+	}
+	if err := p.hooks.OnValue(slices.Clone(currentPath), detectedType); err != nil {
+		return fmt.Errorf("value hook at path %s: %w", PathToString(currentPath), err)
+	}
+	return nil
+}
+
+func (p *parser) onSkip(currentPath []string) {
+	if p.hooks.OnSkip != nil {
+		p.hooks.OnSkip(slices.Clone(currentPath))
+	}
+}
+
+func (p *parser) shouldParse(currentPath []string) bool {
+	currentPath = currentPath[min(p.pathOffset, len(currentPath)):]
+	if p.maxDepth > 0 && len(currentPath) > p.maxDepth {
 		return false
 	}
-	if len(parseObjects) == 0 {
+	if len(p.parseObjects) == 0 {
 		// blacklist scenario
-		for _, ignoreObject := range ignoreObjects {
-			if pathMatches(ignoreObject, currentPath) {
+		for _, ignoreObject := range p.ignoreObjects {
+			if pathHasPrefix(currentPath, ignoreObject) {
 				return false
 			}
 		}
@@ -291,15 +556,60 @@ func (p *parser) shouldParse(
 	}
 
 	// whitelist scenario
-	for _, parseObject := range parseObjects {
+	for _, parseObject := range p.parseObjects {
 		if !pathMatches(currentPath, parseObject) {
 			return false
 		}
-		for _, ignoreObject := range ignoreObjects {
-			if pathMatches(ignoreObject, currentPath) {
+		for _, ignoreObject := range p.ignoreObjects {
+			if pathHasPrefix(currentPath, ignoreObject) {
 				return false
 			}
 		}
 	}
 	return true
 }
+
+// treeSink builds a FieldInfo tree
+type treeSink struct {
+	root  *FieldInfo
+	stack []*FieldInfo
+	// trees of concatenated values
+	documents []*FieldInfo
+}
+
+func (t *treeSink) value(path []string, detectedType DetectedType, decimal DecimalInfo, intType DetectedType) {
+	item := t.add(path, detectedType)
+	item.Decimal = decimal
+	item.IntType = intType
+}
+
+func (t *treeSink) enter(path []string, detectedType DetectedType) {
+	t.stack = append(t.stack, t.add(path, detectedType))
+}
+
+func (t *treeSink) leave() {
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+func (t *treeSink) endDocument() {
+	t.documents = append(t.documents, t.root)
+	t.root, t.stack = nil, nil
+}
+
+func (t *treeSink) add(path []string, detectedType DetectedType) *FieldInfo {
+	item := &FieldInfo{
+		Path:        slices.Clone(path),
+		Type:        detectedType,
+		Children:    make([]*FieldInfo, 0),
+		ChildrenMap: make(map[string]*FieldInfo, 0),
+	}
+	if len(t.stack) == 0 {
+		t.root = item
+		return item
+	}
+	parent := t.stack[len(t.stack)-1]
+	item.Parent = parent
+	parent.Children = append(parent.Children, item)
+	parent.ChildrenMap[lastPathSegment(path)] = item
+	return item
+}
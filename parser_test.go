@@ -0,0 +1,425 @@
+package jsontype_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/4nd3r5on/jsontype"
+)
+
+func TestParseNDJSON(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	input := "{\"a\": 1, \"b\": \"x\"}\n{\"a\": 2}\n\n{\"a\": 3, \"skip\": {\"x\": 1}}\n"
+
+	root, err := jsontype.ParseNDJSON(
+		jsontype.NewJSONStream(strings.NewReader(input)),
+		nil, [][]string{{"skip"}},
+		0, true, logger,
+	)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if root.Type != jsontype.TypeArray {
+		t.Fatalf("expected virtual root array, got %s", root.Type)
+	}
+	if len(root.Children) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(root.Children))
+	}
+
+	merger := jsontype.MergeFieldInfo(nil, "test", root, logger)
+	record, exists := merger.ChildrenMap[""]
+	if !exists {
+		t.Fatalf("expected records to collapse into a wildcard child")
+	}
+	if _, hasA := record.ChildrenMap["a"]; !hasA {
+		t.Errorf("field 'a' is missing")
+	}
+	if b, hasB := record.ChildrenMap["b"]; !hasB {
+		t.Errorf("field 'b' is missing")
+	} else if !b.IsOptional() {
+		t.Errorf("field 'b' should be optional")
+	}
+	if _, hasSkip := record.ChildrenMap["skip"]; hasSkip {
+		t.Errorf("ignored path 'skip' must be relative to each record")
+	}
+}
+
+// TestParse_PathFilters checks that an ignored path matches as a prefix, so the root
+// (and every NDJSON record root) is never skipped
+func TestParse_PathFilters(t *testing.T) {
+	input := `{"a": {"x": 1}, "b": 2, "c": 3, "items": [{"id": 1, "tmp": 0}, {"id": 2, "tmp": 0}]}`
+	tests := []struct {
+		name string
+		opts []jsontype.ParseOption
+		want []string
+	}{
+		{
+			name: "ignore keeps the root and siblings",
+			opts: []jsontype.ParseOption{jsontype.WithIgnoreObjects([]string{"a"})},
+			want: []string{"$", "$.b", "$.c", "$.items", "$.items[0]", "$.items[0].id", "$.items[0].tmp",
+				"$.items[1]", "$.items[1].id", "$.items[1].tmp"},
+		},
+		{
+			name: "ignore nested path",
+			opts: []jsontype.ParseOption{jsontype.WithIgnoreObjects([]string{"a", "x"})},
+			want: []string{"$", "$.a", "$.b", "$.c", "$.items", "$.items[0]", "$.items[0].id", "$.items[0].tmp",
+				"$.items[1]", "$.items[1].id", "$.items[1].tmp"},
+		},
+		{
+			name: "ignore inside parse object",
+			opts: []jsontype.ParseOption{
+				jsontype.WithParseObjects([]string{"items"}),
+				jsontype.WithIgnoreObjects([]string{"items", "0"}),
+			},
+			want: []string{"$", "$.items", "$.items[1]", "$.items[1].id", "$.items[1].tmp"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := append([]jsontype.ParseOption{jsontype.WithLogger(slog.New(slog.DiscardHandler))}, tt.opts...)
+			root, err := jsontype.Parse(jsontype.NewJSONStream(strings.NewReader(input)), opts...)
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if root == nil {
+				t.Fatalf("root was skipped")
+			}
+			var got []string
+			var walk func(field *jsontype.FieldInfo)
+			walk = func(field *jsontype.FieldInfo) {
+				got = append(got, jsontype.PathToString(field.Path))
+				for _, child := range field.Children {
+					walk(child)
+				}
+			}
+			walk(root)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNumberAnalysis_EpochTypes(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	input := `{"created": 1718000000, "ms": 1718000000000, "ns": 1718000000000000000, "count": 5, "mixed": 1718000000, "late": 7}
+		{"created": 1718000001, "ms": 1718000000001, "ns": 1718000000000000001, "count": 1718000000, "mixed": 1718000000000, "late": 4000000000}`
+	opts := []jsontype.ParseOption{
+		jsontype.WithLogger(logger), jsontype.WithNDJSON(), jsontype.WithNumberAnalysis(), jsontype.WithoutStringAnalysis(),
+	}
+
+	root, err := jsontype.Parse(jsontype.NewJSONStream(strings.NewReader(input)), opts...)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	merged := jsontype.MergeFieldInfo(nil, "test", root, logger)
+	streamed, err := jsontype.MergeStream(nil, "test", jsontype.NewJSONStream(strings.NewReader(input)), opts...)
+	if err != nil {
+		t.Fatalf("merge stream: %v", err)
+	}
+
+	// timestamps mixed with other numbers go back to the width they were read with
+	expect := map[string]map[jsontype.DetectedType]int{
+		"created": {jsontype.TypeUnixSeconds: 2},
+		"ms":      {jsontype.TypeUnixMillis: 2},
+		"ns":      {jsontype.TypeUnixNanos: 2},
+		"count":   {jsontype.TypeInt32: 2},
+		"mixed":   {jsontype.TypeInt32: 1, jsontype.TypeInt64: 1},
+		"late":    {jsontype.TypeInt32: 1, jsontype.TypeInt64: 1},
+	}
+	for name, merger := range map[string]*jsontype.Merger{"tree": merged, "stream": streamed} {
+		jsontype.ResolveEpochTypes(merger)
+		record := merger.ChildrenMap[""]
+		for field, want := range expect {
+			got := record.ChildrenMap[field]
+			if !maps.Equal(got.TypesMap, want) {
+				t.Errorf("%s: field %q: got %v, want %v", name, field, got.TypesMap, want)
+			}
+			if labeled := got.LabeledTypesMap["test"]; !maps.Equal(labeled, want) {
+				t.Errorf("%s: field %q: labeled types %v, want %v", name, field, labeled, want)
+			}
+		}
+	}
+}
+
+func TestDetectNumberLiteral(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    jsontype.DetectedType
+		decimal jsontype.DecimalInfo
+	}{
+		{"42", jsontype.TypeInt32, jsontype.DecimalInfo{}},
+		{"-3000000000", jsontype.TypeInt64, jsontype.DecimalInfo{}},
+		{"18446744073709551615", jsontype.TypeUint64, jsontype.DecimalInfo{}},
+		{"-9223372036854775809", jsontype.TypeBigInt, jsontype.DecimalInfo{}},
+		{"123456789012345678901234567890", jsontype.TypeBigInt, jsontype.DecimalInfo{}},
+		{"1.0", jsontype.TypeFloat64, jsontype.DecimalInfo{IntegerDigits: 1, Scale: 1}},
+		{"-123.4500", jsontype.TypeFloat64, jsontype.DecimalInfo{IntegerDigits: 3, Scale: 4}},
+		{"0.005", jsontype.TypeFloat64, jsontype.DecimalInfo{Scale: 3}},
+		{"1.5e3", jsontype.TypeFloat64, jsontype.DecimalInfo{IntegerDigits: 4}},
+		{"1.5E-3", jsontype.TypeFloat64, jsontype.DecimalInfo{Scale: 4}},
+		{"0.00", jsontype.TypeFloat64, jsontype.DecimalInfo{Scale: 2}},
+	}
+	for _, tt := range tests {
+		got, decimal := jsontype.DetectNumberLiteral(tt.in)
+		if got != tt.want || decimal != tt.decimal {
+			t.Errorf("%s: got %s %+v, want %s %+v", tt.in, got, decimal, tt.want, tt.decimal)
+		}
+	}
+}
+
+func TestNumberPrecision(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	input := `{"id": 9007199254740993, "price": 10.5, "big": 18446744073709551615}
+		{"id": 9007199254740995, "price": 1234.125, "big": 1}`
+	want := jsontype.DecimalInfo{IntegerDigits: 4, Scale: 3}
+
+	root, err := jsontype.Parse(jsontype.NewJSONStream(strings.NewReader(input)),
+		jsontype.WithLogger(logger), jsontype.WithNDJSON())
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	merged := jsontype.MergeFieldInfo(nil, "test", root, logger)
+	streamed, err := jsontype.MergeStream(nil, "test", jsontype.NewJSONStream(strings.NewReader(input)),
+		jsontype.WithLogger(logger), jsontype.WithNDJSON())
+	if err != nil {
+		t.Fatalf("merge stream: %v", err)
+	}
+
+	for name, merger := range map[string]*jsontype.Merger{"tree": merged, "stream": streamed} {
+		record := merger.ChildrenMap[""]
+		// 2^53 + 1 isn't a float64
+		if id := record.ChildrenMap["id"].TypesMap; len(id) != 1 || id[jsontype.TypeInt64] != 2 {
+			t.Errorf("%s: id: expected only int64, got %v", name, id)
+		}
+		if big := record.ChildrenMap["big"].TypesMap; big[jsontype.TypeUint64] != 1 || big[jsontype.TypeInt32] != 1 {
+			t.Errorf("%s: big: expected uint64 and int32, got %v", name, big)
+		}
+		if got := record.ChildrenMap["price"].Decimal; got != want {
+			t.Errorf("%s: price: got %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	logger := slog.New(slog.DiscardHandler)
+	tests := []struct {
+		name    string
+		input   string
+		stream  func(string) jsontype.Stream
+		path    string
+		line    int
+		column  int
+		snippet string
+	}{
+		{
+			name:    "json",
+			input:   "{\n  \"a\": 1,\n  \"b\": [1, 2,, 3]\n}",
+			stream:  func(s string) jsontype.Stream { return jsontype.NewJSONStream(strings.NewReader(s)) },
+			path:    "$.b[2]",
+			line:    3,
+			column:  14,
+			snippet: "3 |   \"b\": [1, 2,, 3]\n  |              ^",
+		},
+		{
+			name:    "json5",
+			input:   "// comment\n{a: 1,\n\tb: @}",
+			stream:  func(s string) jsontype.Stream { return jsontype.NewJSON5Stream(strings.NewReader(s)) },
+			path:    "$.b",
+			line:    3,
+			column:  5,
+			snippet: "3 | \tb: @}\n  | \t   ^",
+		},
+		{
+			name:   "long line",
+			input:  `{"a": "` + strings.Repeat("x", 200) + `", "b": nul}`,
+			stream: func(s string) jsontype.Stream { return jsontype.NewJSONStream(strings.NewReader(s)) },
+			path:   "$.b",
+			line:   1,
+			column: 219,
+			snippet: "1 | ..." + strings.Repeat("x", 49) + `", "b": nul}` + "\n" +
+				"  | " + strings.Repeat(" ", 63) + "^",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := jsontype.MergeStream(nil, "input", tt.stream(tt.input), jsontype.WithLogger(logger))
+			var parseErr *jsontype.ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("expected a ParseError, got %v", err)
+			}
+			if parseErr.Label != "input" || jsontype.PathToString(parseErr.Path) != tt.path {
+				t.Errorf("got label %q path %s, want input %s", parseErr.Label, jsontype.PathToString(parseErr.Path), tt.path)
+			}
+			if parseErr.Line != tt.line || parseErr.Column != tt.column {
+				t.Errorf("got %d:%d, want %d:%d", parseErr.Line, parseErr.Column, tt.line, tt.column)
+			}
+			if parseErr.Snippet != tt.snippet {
+				t.Errorf("got snippet\n%s\nwant\n%s", parseErr.Snippet, tt.snippet)
+			}
+		})
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	input := `{"id": "admin@email.com", "list": [1, [2, 3]], "debug": {"x": 1}, "n": 1}`
+
+	var values, skipped []string
+	root, err := jsontype.Parse(
+		jsontype.NewJSONStream(strings.NewReader(input)),
+		jsontype.WithIgnoreObjects([]string{"debug"}),
+		jsontype.WithMaxDepth(2),
+		jsontype.WithoutStringAnalysis(),
+		jsontype.WithLogger(slog.New(slog.DiscardHandler)),
+		jsontype.WithHooks(jsontype.ParseHooks{
+			OnValue: func(path []string, typ jsontype.DetectedType) error {
+				values = append(values, jsontype.PathToString(path)+" "+string(typ))
+				return nil
+			},
+			OnSkip: func(path []string) {
+				skipped = append(skipped, jsontype.PathToString(path))
+			},
+		}),
+	)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if root.ChildrenMap["id"].Type != jsontype.TypeString {
+		t.Errorf("string analysis must be disabled, got %s", root.ChildrenMap["id"].Type)
+	}
+	// values skipped by the depth limit must not swallow the closing delimiter
+	if n := root.ChildrenMap["n"]; n == nil || n.Type != jsontype.TypeInt32 {
+		t.Errorf("field 'n' after the skipped array elements is missing")
+	}
+
+	expectValues := "$ object, $.id string, $.list array, $.list[0] int32, $.list[1] array, $.n int32"
+	if got := strings.Join(values, ", "); got != expectValues {
+		t.Errorf("unexpected values\nGot:      %s\nExpected: %s", got, expectValues)
+	}
+	expectSkipped := "$.list[1][0], $.list[1][1], $.debug"
+	if got := strings.Join(skipped, ", "); got != expectSkipped {
+		t.Errorf("unexpected skipped paths\nGot:      %s\nExpected: %s", got, expectSkipped)
+	}
+
+	// a hook error aborts parsing
+	_, err = jsontype.Parse(
+		jsontype.NewJSONStream(strings.NewReader(input)),
+		jsontype.WithLogger(slog.New(slog.DiscardHandler)),
+		jsontype.WithHooks(jsontype.ParseHooks{
+			OnValue: func(path []string, typ jsontype.DetectedType) error {
+				if typ == jsontype.TypeArray {
+					return errors.New("arrays aren't allowed")
+				}
+				return nil
+			},
+		}),
+	)
+	if err == nil || !strings.Contains(err.Error(), "arrays aren't allowed") {
+		t.Errorf("expected the hook error, got %v", err)
+	}
+}
+
+func TestParseLimits(t *testing.T) {
+	input := `{"a": [1, "xy", [{"q": "long"}]], "b": 1, "c": 2}`
+
+	tests := []struct {
+		limits jsontype.ParseLimits
+		kind   jsontype.LimitKind
+	}{
+		{jsontype.ParseLimits{MaxBytes: 10}, jsontype.LimitBytes},
+		{jsontype.ParseLimits{MaxTokens: 5}, jsontype.LimitTokens},
+		{jsontype.ParseLimits{MaxNesting: 2}, jsontype.LimitNesting},
+		{jsontype.ParseLimits{MaxObjectKeys: 2}, jsontype.LimitObjectKeys},
+		{jsontype.ParseLimits{MaxStringLength: 3}, jsontype.LimitStringLength},
+	}
+	for _, tt := range tests {
+		_, err := jsontype.Parse(
+			jsontype.NewJSONStream(strings.NewReader(input)),
+			jsontype.WithLimits(tt.limits),
+			jsontype.WithLogger(slog.New(slog.DiscardHandler)),
+		)
+		var limitErr *jsontype.LimitError
+		if !errors.As(err, &limitErr) || limitErr.Limit != tt.kind {
+			t.Errorf("%s: expected a limit error, got %v", tt.kind, err)
+			continue
+		}
+		if !errors.Is(err, jsontype.ErrLimitExceeded) {
+			t.Errorf("%s: error must match ErrLimitExceeded", tt.kind)
+		}
+	}
+
+	// elements over the limit are skipped, not reported
+	root, err := jsontype.Parse(
+		jsontype.NewJSONStream(strings.NewReader(input)),
+		jsontype.WithLimits(jsontype.ParseLimits{MaxArrayElements: 1, MaxBytes: int64(len(input))}),
+		jsontype.WithLogger(slog.New(slog.DiscardHandler)),
+	)
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if n := len(root.ChildrenMap["a"].Children); n != 1 {
+		t.Errorf("expected a single analyzed element, got %d", n)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = jsontype.Parse(
+		jsontype.NewJSONStream(strings.NewReader(input)),
+		jsontype.WithContext(ctx),
+		jsontype.WithLogger(slog.New(slog.DiscardHandler)),
+	)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+// cancelReader cancels the context once more than after bytes were read
+type cancelReader struct {
+	r      io.Reader
+	after  int
+	read   int
+	cancel context.CancelFunc
+}
+
+func (r *cancelReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.read += n
+	if r.read > r.after {
+		r.cancel()
+	}
+	return n, err
+}
+
+func TestParse_CancelWhileSkipping(t *testing.T) {
+	elements := strings.Repeat("1,", 100000) + "1"
+	tests := []struct {
+		name  string
+		input string
+		opt   jsontype.ParseOption
+	}{
+		{"ignored path", `{"skip": [` + elements + `], "a": 1}`, jsontype.WithIgnoreObjects([]string{"skip"})},
+		{"elements over the limit", `[` + elements + `]`, jsontype.WithLimits(jsontype.ParseLimits{MaxArrayElements: 1})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			r := &cancelReader{r: strings.NewReader(tt.input), after: len(tt.input) / 2, cancel: cancel}
+			_, err := jsontype.Parse(
+				jsontype.NewJSONStream(r),
+				tt.opt,
+				jsontype.WithContext(ctx),
+				jsontype.WithLogger(slog.New(slog.DiscardHandler)),
+			)
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("expected context.Canceled, got %v", err)
+			}
+		})
+	}
+}
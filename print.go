@@ -8,7 +8,7 @@ import (
 	"strings"
 )
 
-func collectTypes(m map[DetectedType]struct{}) []DetectedType {
+func collectTypes[V any](m map[DetectedType]V) []DetectedType {
 	out := make([]DetectedType, 0, len(m))
 	for t := range m {
 		out = append(out, t)
@@ -17,7 +17,7 @@ func collectTypes(m map[DetectedType]struct{}) []DetectedType {
 	return out
 }
 
-func collectLabels(m map[string]map[DetectedType]struct{}) []string {
+func collectLabels[V any](m map[string]V) []string {
 	out := make([]string, 0, len(m))
 	for k := range m {
 		out = append(out, k)
@@ -36,6 +36,21 @@ func collectChildTypes(m *Merger) []DetectedType {
 	return collectTypes(seen)
 }
 
+// formatPercent renders n/total as a rounded percentage
+func formatPercent(n, total int) string {
+	if total == 0 {
+		return "0%"
+	}
+	pct := float64(n) * 100 / float64(total)
+	switch {
+	case pct > 0 && pct < 1:
+		return "<1%"
+	case pct > 99 && pct < 100:
+		return ">99%"
+	}
+	return fmt.Sprintf("%.0f%%", pct)
+}
+
 func PrintMergerTree(m *Merger, prefix string, w io.Writer) {
 	if m == nil {
 		return
@@ -58,59 +73,26 @@ func printNode(m *Merger, prefix string, w io.Writer) {
 	types := collectTypes(m.TypesMap)
 	labels := collectLabels(m.LabeledTypesMap)
 
-	// split container vs primitive
-	var containers []DetectedType
-	var primitives []DetectedType
-
-	for _, t := range types {
-		if IsContainerType(t) {
-			containers = append(containers, t)
-		} else {
-			primitives = append(primitives, t)
-		}
+	// optional keys (missing from some parent objects) are marked with "?"
+	presence := ""
+	if m.IsOptional() {
+		path += "?"
+		presence = fmt.Sprintf(" [present in %s]", formatPercent(m.Occurrences, m.ParentOccurrences))
 	}
 
-	// ----- CONTAINERS -----
-	if len(containers) > 0 {
-		var rendered []string
+	hasContainers := slices.ContainsFunc(types, IsContainerType)
 
-		for _, ct := range containers {
-			inner := "unknown"
-
-			if len(m.ChildrenMap) > 0 {
-				childTypes := collectChildTypes(m)
-				if len(childTypes) > 0 {
-					inner = strings.Join(TypesToString(childTypes), " | ")
-				}
-			}
-
-			rendered = append(
-				rendered,
-				fmt.Sprintf("%s<%s>", ct, inner),
-			)
-		}
-
-		fmt.Fprintf(
-			w,
-			"%s%s => %s\n",
-			prefix,
-			path,
-			strings.Join(rendered, " | "),
-		)
-		return
-	}
-
-	// ----- PRIMITIVES -----
-	if len(labels) > 1 && len(primitives) > 1 {
+	// ----- PRIMITIVES BY LABEL -----
+	if !hasContainers && len(labels) > 1 && len(types) > 1 {
 		for _, lbl := range labels {
-			lblTypes := collectTypes(m.LabeledTypesMap[lbl])
 			fmt.Fprintf(
 				w,
-				"%s%s @ %s => %s\n",
+				"%s%s @ %s => %s%s\n",
 				prefix,
 				path,
 				lbl,
-				strings.Join(TypesToString(lblTypes), " | "),
+				renderTypes(m, m.LabeledTypesMap[lbl]),
+				presence,
 			)
 		}
 		return
@@ -118,9 +100,44 @@ func printNode(m *Merger, prefix string, w io.Writer) {
 
 	fmt.Fprintf(
 		w,
-		"%s%s => %s\n",
+		"%s%s => %s%s\n",
 		prefix,
 		path,
-		strings.Join(TypesToString(primitives), " | "),
+		renderTypes(m, m.TypesMap),
+		presence,
 	)
 }
+
+// renderTypes joins types with their share of observations,
+// containers are rendered with types of their children
+func renderTypes(m *Merger, counts map[DetectedType]int) string {
+	types := collectTypes(counts)
+
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+
+	rendered := make([]string, 0, len(types))
+	for _, t := range types {
+		name := string(t)
+		if IsContainerType(t) {
+			inner := "unknown"
+			if len(m.ChildrenMap) > 0 {
+				childTypes := collectChildTypes(m)
+				if len(childTypes) > 0 {
+					inner = strings.Join(TypesToString(childTypes), " | ")
+				}
+			}
+			name = fmt.Sprintf("%s<%s>", t, inner)
+		}
+		if t == TypeFloat64 && !m.Decimal.IsZero() {
+			name = fmt.Sprintf("%s decimal(%d,%d)", name, m.Decimal.Precision(), m.Decimal.Scale)
+		}
+		if len(types) > 1 {
+			name = fmt.Sprintf("%s (%s)", name, formatPercent(counts[t], total))
+		}
+		rendered = append(rendered, name)
+	}
+	return strings.Join(rendered, " | ")
+}
@@ -15,6 +15,7 @@ type Stream interface {
 	//   - [Number], for JSON numbers
 	//   - string, for JSON string literals
 	//   - nil, for JSON null
+	//   - [TypedValue], for values of binary formats without a JSON equivalent
 	//
 	// At the end of the stream returns EOF
 	Token() (json.Token, error)
@@ -22,15 +23,44 @@ type Stream interface {
 	SkipValue() error
 }
 
+// TypedValue is a token of a scalar that has no JSON equivalent, e.g. binary data
+// or a BSON ObjectId. Its type is recorded as is, see JSONEquivalentType
+type TypedValue struct {
+	Type DetectedType
+}
+
 type DefaultStream struct {
 	*json.Decoder
+	reader *limitedReader
 }
 
+// NewJSONStream reads JSON numbers as json.Number, so integers beyond 2^53
+// and the digits of decimals are kept
 func NewJSONStream(r io.Reader) *DefaultStream {
-	return &DefaultStream{Decoder: json.NewDecoder(r)}
+	reader := &limitedReader{r: r, lines: newLineTracker()}
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+	return &DefaultStream{Decoder: decoder, reader: reader}
+}
+
+// SetReadLimit makes reads fail with a LimitError after n bytes, n <= 0 means unlimited
+func (s *DefaultStream) SetReadLimit(n int64) {
+	s.reader.limit = n
+}
+
+func (s *DefaultStream) locate(offset int64) (line, column int, snippet string) {
+	if s.reader == nil || s.reader.lines == nil {
+		return 0, 0, ""
+	}
+	return s.reader.lines.locate(offset)
 }
 
 func (s *DefaultStream) SkipValue() error {
+	return skipValue(s)
+}
+
+// skipValue reads tokens of the next value, including nested ones
+func skipValue(s Stream) error {
 	token, err := s.Token()
 	if err != nil {
 		return err
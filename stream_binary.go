@@ -0,0 +1,180 @@
+package jsontype
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// binaryDecoder reads values of a binary format for a binaryStream
+type binaryDecoder interface {
+	// next reads the next value: a scalar token, or '{' / '[' with the number
+	// of entries (-1 if the container is terminated by a marker)
+	next() (json.Token, int, error)
+	// key reads the key of the next object entry
+	key() (string, error)
+	// element is called before every array element
+	element() error
+	// atEnd reports if a terminated container ends next
+	atEnd() (bool, error)
+	// close reads the end marker of a terminated container
+	close() error
+}
+
+// binaryFrame is an open container of a binaryStream
+type binaryFrame struct {
+	object bool
+	// entries left, -1 for terminated containers
+	remaining int
+}
+
+// binaryStream emits tokens of a binary format read by the decoder
+type binaryStream struct {
+	r       *binaryReader
+	decoder binaryDecoder
+	stack   []binaryFrame
+	// a key was read, its value is next
+	valueNext bool
+}
+
+func (s *binaryStream) Token() (json.Token, error) {
+	if n := len(s.stack); n > 0 && !s.valueNext {
+		top := &s.stack[n-1]
+		ended, err := s.ended(top)
+		if err != nil {
+			return nil, err
+		}
+		if ended {
+			s.stack = s.stack[:n-1]
+			if top.object {
+				return json.Delim('}'), nil
+			}
+			return json.Delim(']'), nil
+		}
+		if top.remaining > 0 {
+			top.remaining--
+		}
+		if top.object {
+			key, err := s.decoder.key()
+			if err != nil {
+				return nil, unexpectedEOF(err)
+			}
+			s.valueNext = true
+			return key, nil
+		}
+		if err := s.decoder.element(); err != nil {
+			return nil, unexpectedEOF(err)
+		}
+	}
+
+	inContainer := len(s.stack) > 0
+	s.valueNext = false
+	token, length, err := s.decoder.next()
+	if err != nil {
+		if inContainer {
+			return nil, unexpectedEOF(err)
+		}
+		return nil, err
+	}
+	if d, isDelim := token.(json.Delim); isDelim {
+		s.stack = append(s.stack, binaryFrame{object: d == '{', remaining: length})
+	}
+	return token, nil
+}
+
+// ended reports if the container has no more entries, reading the end marker
+func (s *binaryStream) ended(top *binaryFrame) (bool, error) {
+	if top.remaining >= 0 {
+		return top.remaining == 0, nil
+	}
+	ended, err := s.decoder.atEnd()
+	if err != nil || !ended {
+		return false, unexpectedEOF(err)
+	}
+	return true, s.decoder.close()
+}
+
+// More reports if the current container has more entries,
+// or if there is another value at the top level
+func (s *binaryStream) More() bool {
+	if s.valueNext {
+		return true
+	}
+	if len(s.stack) == 0 {
+		_, err := s.r.Peek(1)
+		// errors other than EOF are returned by the next Token
+		return err != io.EOF
+	}
+	top := s.stack[len(s.stack)-1]
+	if top.remaining >= 0 {
+		return top.remaining > 0
+	}
+	ended, err := s.decoder.atEnd()
+	return err != nil || !ended
+}
+
+// InputOffset returns the offset of the next unread byte
+func (s *binaryStream) InputOffset() int64 {
+	return s.r.limited.read - int64(s.r.Buffered())
+}
+
+func (s *binaryStream) SkipValue() error {
+	return skipValue(s)
+}
+
+// binaryReader reads binary formats
+type binaryReader struct {
+	*bufio.Reader
+	limited *limitedReader
+}
+
+func newBinaryReader(r io.Reader) *binaryReader {
+	limited := &limitedReader{r: r}
+	return &binaryReader{Reader: bufio.NewReader(limited), limited: limited}
+}
+
+// maxChunk bounds allocations for lengths read from the input
+const maxChunk = 1 << 16
+
+// readN reads n bytes, memory grows with the data actually read rather than the declared length
+func (r *binaryReader) readN(n uint64) ([]byte, error) {
+	if n <= maxChunk {
+		b := make([]byte, n)
+		_, err := io.ReadFull(r, b)
+		return b, unexpectedEOF(err)
+	}
+	b, err := io.ReadAll(io.LimitReader(r, int64(min(n, 1<<62))))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(b)) != n {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return b, nil
+}
+
+// skip discards n bytes
+func (r *binaryReader) skip(n uint64) error {
+	if n > 1<<62 {
+		return fmt.Errorf("invalid length %d", n)
+	}
+	discarded, err := io.CopyN(io.Discard, r, int64(n))
+	if err != nil && uint64(discarded) < n {
+		return unexpectedEOF(err)
+	}
+	return nil
+}
+
+// uint reads a big-endian unsigned integer of size bytes
+func (r *binaryReader) uint(size int) (uint64, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(r, buf[:size]); err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	var v uint64
+	for _, b := range buf[:size] {
+		v = v<<8 | uint64(b)
+	}
+	return v, nil
+}
@@ -0,0 +1,215 @@
+package jsontype_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/4nd3r5on/jsontype"
+)
+
+// pathTypes returns types met at every path of the merged tree
+func pathTypes(m *jsontype.Merger) map[string]string {
+	out := make(map[string]string)
+	var walk func(m *jsontype.Merger)
+	walk = func(m *jsontype.Merger) {
+		types := make([]string, 0, len(m.TypesMap))
+		for t := range m.TypesMap {
+			types = append(types, string(t))
+		}
+		slices.Sort(types)
+		out[jsontype.PathToString(m.Path)] = strings.Join(types, "|")
+		for _, key := range m.ChildrenKeys {
+			walk(m.ChildrenMap[key])
+		}
+	}
+	walk(m)
+	return out
+}
+
+func checkPathTypes(t *testing.T, s jsontype.Stream, want map[string]string) {
+	t.Helper()
+	merger, err := jsontype.MergeStream(jsontype.NewMerger([]string{}), "test", s,
+		jsontype.WithLogger(slog.New(slog.DiscardHandler)), jsontype.WithoutStringAnalysis())
+	if err != nil {
+		t.Fatalf("merge stream: %v", err)
+	}
+	got := pathTypes(merger)
+	for _, path := range slices.Sorted(maps.Keys(want)) {
+		if got[path] != want[path] {
+			t.Errorf("%s: got %q, want %q", path, got[path], want[path])
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("got paths %v, want %v", slices.Sorted(maps.Keys(got)), slices.Sorted(maps.Keys(want)))
+	}
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	var digits strings.Builder
+	for _, line := range strings.Split(s, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		digits.WriteString(strings.Join(strings.Fields(line), ""))
+	}
+	b, err := hex.DecodeString(digits.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestMsgPackStream(t *testing.T) {
+	data := mustHex(t, `
+		88
+		a2 6964 01                             # id: 1
+		a4 6e616d65 a1 61                      # name: "a"
+		a4 626c6f62 c4 03 010203               # blob: bin
+		a2 6174 d6 ff 65000000                 # at: timestamp
+		a4 74616773 92 a1 78 a1 79             # tags: ["x", "y"]
+		a5 73636f7265 cb 3ff8000000000000      # score: 1.5
+		a3 626967 cf ffffffffffffffff          # big: uint64
+		a1 6d 82 01 c3 02 d4 05 00             # m: {1: true, 2: ext}
+	`)
+	checkPathTypes(t, jsontype.NewMsgPackStream(bytes.NewReader(data)), map[string]string{
+		"$":        "object",
+		"$.id":     "int32",
+		"$.name":   "string",
+		"$.blob":   "binary",
+		"$.at":     "datetime",
+		"$.tags":   "array",
+		"$.tags[]": "string",
+		"$.score":  "float64",
+		"$.big":    "uint64",
+		"$.m":      "object_int",
+		"$.m[1]":   "bool",
+		"$.m[2]":   "msgpack-ext",
+	})
+}
+
+func TestCBORStream(t *testing.T) {
+	data := mustHex(t, `
+		d9d9f7 aa                              # self-described, map of 10
+		62 6964 01                             # id: 1
+		63 6e6567 3b ffffffffffffffff          # neg: -2^64
+		64 626c6f62 43 010203                  # blob: bytes
+		62 6174 c1 1a 65000000                 # at: epoch datetime
+		63 756964 d825 50 00112233445566778899aabbccddeeff # uid: UUID
+		64 74616773 9f 61 78 61 79 ff          # tags: indefinite ["x", "y"]
+		64 68616c66 f9 3e00                    # half: 1.5
+		64 74657874 7f 61 61 61 62 ff          # text: indefinite "ab"
+		63 756e6b d90100 a1 01 9f 02 ff        # unk: tag 256 {1: [2]}
+		61 6d a2 01 f5 02 f6                   # m: {1: true, 2: null}
+	`)
+	checkPathTypes(t, jsontype.NewCBORStream(bytes.NewReader(data)), map[string]string{
+		"$":        "object",
+		"$.id":     "int32",
+		"$.neg":    "bigint",
+		"$.blob":   "binary",
+		"$.at":     "datetime",
+		"$.uid":    "string-uuid",
+		"$.tags":   "array",
+		"$.tags[]": "string",
+		"$.half":   "float64",
+		"$.text":   "string",
+		"$.unk":    "cbor-tag",
+		"$.m":      "object_int",
+		"$.m[]":    "bool|null",
+	})
+}
+
+// bsonDocument encodes elements as a BSON document
+func bsonDocument(elements ...[]byte) []byte {
+	body := bytes.Join(elements, nil)
+	doc := binary.LittleEndian.AppendUint32(nil, uint32(len(body)+5))
+	return append(append(doc, body...), 0)
+}
+
+func bsonElement(elementType byte, name string, value ...byte) []byte {
+	return append(append([]byte{elementType}, name+"\x00"...), value...)
+}
+
+func bsonString(s string) []byte {
+	return append(binary.LittleEndian.AppendUint32(nil, uint32(len(s)+1)), s+"\x00"...)
+}
+
+func TestBSONStream(t *testing.T) {
+	first := bsonDocument(
+		bsonElement(0x07, "_id", make([]byte, 12)...),
+		bsonElement(0x02, "name", bsonString("widget")...),
+		bsonElement(0x09, "created", make([]byte, 8)...),
+		bsonElement(0x13, "price", make([]byte, 16)...),
+		bsonElement(0x10, "qty", 5, 0, 0, 0),
+		bsonElement(0x04, "tags", bsonDocument(
+			bsonElement(0x02, "0", bsonString("a")...),
+			bsonElement(0x02, "1", bsonString("b")...),
+		)...),
+		bsonElement(0x05, "data", 2, 0, 0, 0, 0x00, 0xca, 0xfe),
+		bsonElement(0x05, "uid", append([]byte{16, 0, 0, 0, 0x04}, make([]byte, 16)...)...),
+		bsonElement(0x11, "ts", make([]byte, 8)...),
+		bsonElement(0x0b, "re", []byte("^a\x00i\x00")...),
+		bsonElement(0x03, "sub", bsonDocument(bsonElement(0x08, "ok", 1))...),
+	)
+	second := bsonDocument(
+		bsonElement(0x07, "_id", make([]byte, 12)...),
+		bsonElement(0x0a, "name"),
+		bsonElement(0x01, "price", 0, 0, 0, 0, 0, 0, 0xf8, 0x3f),
+	)
+
+	s := jsontype.NewBSONStream(bytes.NewReader(append(first, second...)))
+	merger, err := jsontype.MergeStream(jsontype.NewMerger([]string{}), "test", s,
+		jsontype.WithLogger(slog.New(slog.DiscardHandler)), jsontype.WithoutStringAnalysis(), jsontype.WithNDJSON())
+	if err != nil {
+		t.Fatalf("merge stream: %v", err)
+	}
+	got := pathTypes(merger)
+	want := map[string]string{
+		"$[]":         "object",
+		"$[]._id":     "bson-objectid",
+		"$[].name":    "null|string",
+		"$[].created": "datetime",
+		"$[].price":   "bson-decimal128|float64",
+		"$[].qty":     "int32",
+		"$[].tags":    "array",
+		"$[].tags[]":  "string",
+		"$[].data":    "binary",
+		"$[].uid":     "string-uuid",
+		"$[].ts":      "bson-timestamp",
+		"$[].re":      "bson-regex",
+		"$[].sub":     "object",
+		"$[].sub.ok":  "bool",
+	}
+	for path, types := range want {
+		if got[path] != types {
+			t.Errorf("%s: got %q, want %q", path, got[path], types)
+		}
+	}
+
+	// generators render the types like their JSON equivalents
+	record := merger.ChildrenMap[""]
+	if schema := jsontype.MergerToJSONSchema(record.ChildrenMap["created"]); schema.Format != "date-time" {
+		t.Errorf("created: got format %q, want date-time", schema.Format)
+	}
+	if schema := jsontype.MergerToJSONSchema(record.ChildrenMap["data"]); schema.ContentEncoding != "base64" {
+		t.Errorf("data: got content encoding %q, want base64", schema.ContentEncoding)
+	}
+}
+
+func TestBinaryStreams_Truncated(t *testing.T) {
+	streams := map[string]jsontype.Stream{
+		"msgpack": jsontype.NewMsgPackStream(bytes.NewReader(mustHex(t, "82 a1 61 01 a1"))),
+		"cbor":    jsontype.NewCBORStream(bytes.NewReader(mustHex(t, "9f 01 02"))),
+		"bson":    jsontype.NewBSONStream(bytes.NewReader(bsonDocument(bsonElement(0x02, "a", bsonString("b")...))[:10])),
+	}
+	for name, s := range streams {
+		_, err := jsontype.MergeStream(nil, "test", s, jsontype.WithLogger(slog.New(slog.DiscardHandler)))
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package jsontype
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// BSON element types
+const (
+	bsonDouble        = 0x01
+	bsonString        = 0x02
+	bsonDocument      = 0x03
+	bsonArray         = 0x04
+	bsonBinary        = 0x05
+	bsonUndefined     = 0x06
+	bsonObjectID      = 0x07
+	bsonBool          = 0x08
+	bsonDateTime      = 0x09
+	bsonNull          = 0x0a
+	bsonRegex         = 0x0b
+	bsonDBPointer     = 0x0c
+	bsonJavaScript    = 0x0d
+	bsonSymbol        = 0x0e
+	bsonCodeWithScope = 0x0f
+	bsonInt32         = 0x10
+	bsonTimestamp     = 0x11
+	bsonInt64         = 0x12
+	bsonDecimal128    = 0x13
+	bsonMinKey        = 0xff
+	bsonMaxKey        = 0x7f
+)
+
+const (
+	// binary subtype of UUIDs
+	bsonBinaryUUID     = 0x04
+	bsonObjectIDLength = 12
+)
+
+// BSONStream reads a sequence of BSON documents, e.g. a mongodump collection file.
+// ObjectIds, dates, Decimal128, timestamps, regular expressions and binary data
+// are returned as TypedValue tokens, JavaScript code and symbols as strings
+type BSONStream struct {
+	binaryStream
+}
+
+func NewBSONStream(r io.Reader) *BSONStream {
+	s := &BSONStream{}
+	s.r = newBinaryReader(r)
+	s.decoder = &bsonDecoder{r: s.r}
+	return s
+}
+
+// SetReadLimit makes reads fail with a LimitError after n bytes, n <= 0 means unlimited
+func (s *BSONStream) SetReadLimit(n int64) {
+	s.r.limited.limit = n
+}
+
+type bsonDecoder struct {
+	r *binaryReader
+	// open documents and arrays
+	depth int
+	// type of the element read by key or element
+	elementType byte
+}
+
+func (d *bsonDecoder) int32() (int32, error) {
+	var b [4]byte
+	if _, err := io.ReadFull(d.r, b[:]); err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	return int32(binary.LittleEndian.Uint32(b[:])), nil
+}
+
+func (d *bsonDecoder) uint64() (uint64, error) {
+	var b [8]byte
+	if _, err := io.ReadFull(d.r, b[:]); err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	return binary.LittleEndian.Uint64(b[:]), nil
+}
+
+// cstring reads a zero-terminated string
+func (d *bsonDecoder) cstring() (string, error) {
+	s, err := d.r.ReadString(0)
+	if err != nil {
+		return "", unexpectedEOF(err)
+	}
+	return s[:len(s)-1], nil
+}
+
+// string reads a length-prefixed zero-terminated string
+func (d *bsonDecoder) string() (string, error) {
+	n, err := d.int32()
+	if err != nil {
+		return "", err
+	}
+	if n < 1 {
+		return "", fmt.Errorf("invalid BSON string length %d", n)
+	}
+	b, err := d.r.readN(uint64(n))
+	if err != nil {
+		return "", err
+	}
+	return string(b[:n-1]), nil
+}
+
+func (d *bsonDecoder) next() (json.Token, int, error) {
+	elementType := d.elementType
+	if d.depth == 0 {
+		// the length of a top-level document, EOF is returned as is
+		if _, err := d.r.Peek(1); err != nil {
+			return nil, 0, err
+		}
+		elementType = bsonDocument
+	}
+
+	switch elementType {
+	case bsonDocument, bsonArray:
+		// the length isn't needed, documents end with a zero byte
+		if _, err := d.int32(); err != nil {
+			return nil, 0, err
+		}
+		d.depth++
+		if elementType == bsonArray {
+			return json.Delim('['), -1, nil
+		}
+		return json.Delim('{'), -1, nil
+	case bsonDouble:
+		bits, err := d.uint64()
+		return math.Float64frombits(bits), 0, err
+	case bsonString, bsonJavaScript, bsonSymbol:
+		s, err := d.string()
+		return s, 0, err
+	case bsonBinary:
+		n, err := d.int32()
+		if err != nil {
+			return nil, 0, err
+		}
+		subtype, err := d.r.ReadByte()
+		if err != nil {
+			return nil, 0, unexpectedEOF(err)
+		}
+		if n < 0 {
+			return nil, 0, fmt.Errorf("invalid BSON binary length %d", n)
+		}
+		t := TypeBinary
+		if subtype == bsonBinaryUUID {
+			t = TypeUUID
+		}
+		return TypedValue{Type: t}, 0, d.r.skip(uint64(n))
+	case bsonUndefined, bsonNull:
+		return nil, 0, nil
+	case bsonObjectID:
+		return TypedValue{Type: TypeObjectID}, 0, d.r.skip(bsonObjectIDLength)
+	case bsonBool:
+		b, err := d.r.ReadByte()
+		return b != 0, 0, unexpectedEOF(err)
+	case bsonDateTime:
+		return TypedValue{Type: TypeDateTime}, 0, d.r.skip(8)
+	case bsonRegex:
+		if _, err := d.cstring(); err != nil { // pattern
+			return nil, 0, err
+		}
+		_, err := d.cstring() // options
+		return TypedValue{Type: TypeRegex}, 0, err
+	case bsonDBPointer:
+		if _, err := d.string(); err != nil { // collection
+			return nil, 0, err
+		}
+		return TypedValue{Type: TypeObjectID}, 0, d.r.skip(bsonObjectIDLength)
+	case bsonCodeWithScope:
+		n, err := d.int32()
+		if err != nil {
+			return nil, 0, err
+		}
+		if n < 4 {
+			return nil, 0, fmt.Errorf("invalid BSON code with scope length %d", n)
+		}
+		return TypedValue{Type: TypeString}, 0, d.r.skip(uint64(n) - 4)
+	case bsonInt32:
+		n, err := d.int32()
+		return json.Number(strconv.Itoa(int(n))), 0, err
+	case bsonTimestamp:
+		return TypedValue{Type: TypeBSONTimestamp}, 0, d.r.skip(8)
+	case bsonInt64:
+		n, err := d.uint64()
+		return json.Number(strconv.FormatInt(int64(n), 10)), 0, err
+	case bsonDecimal128:
+		return TypedValue{Type: TypeDecimal128}, 0, d.r.skip(16)
+	case bsonMinKey, bsonMaxKey:
+		return TypedValue{Type: TypeUnknown}, 0, nil
+	}
+	return nil, 0, fmt.Errorf("invalid BSON element type 0x%02x", elementType)
+}
+
+// key reads the type and the name of the next element
+func (d *bsonDecoder) key() (string, error) {
+	elementType, err := d.r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	d.elementType = elementType
+	return d.cstring()
+}
+
+// element reads the type of the next array element, its name is the index
+func (d *bsonDecoder) element() error {
+	_, err := d.key()
+	return err
+}
+
+func (d *bsonDecoder) atEnd() (bool, error) {
+	b, err := d.r.Peek(1)
+	if err != nil {
+		return false, err
+	}
+	return b[0] == 0, nil
+}
+
+func (d *bsonDecoder) close() error {
+	d.depth--
+	_, err := d.r.ReadByte()
+	return err
+}
@@ -0,0 +1,308 @@
+package jsontype
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// CBOR major types
+const (
+	cborUint = iota
+	cborNegint
+	cborBytes
+	cborText
+	cborArray
+	cborMap
+	cborTag
+	cborSimple
+)
+
+// cborBreak ends containers and strings of indefinite length
+const cborBreak = 0xff
+
+// cborTagTypes are types of well-known tags, their content isn't analyzed
+var cborTagTypes = map[uint64]DetectedType{
+	0:  TypeDateTime, // date/time string
+	1:  TypeDateTime, // epoch-based date/time
+	4:  TypeFloat64,  // decimal fraction
+	5:  TypeFloat64,  // bigfloat
+	32: TypeLink,     // URI
+	37: TypeUUID,
+}
+
+// cborSelfDescribed is the tag marking CBOR data, it doesn't change the content
+const cborSelfDescribed = 55799
+
+// CBORStream reads a sequence of CBOR data items (RFC 8949, RFC 8742).
+// Byte strings and tagged values are returned as TypedValue tokens, bignums as numbers,
+// integer map keys are read as strings
+type CBORStream struct {
+	binaryStream
+}
+
+func NewCBORStream(r io.Reader) *CBORStream {
+	s := &CBORStream{}
+	s.r = newBinaryReader(r)
+	s.decoder = &cborDecoder{r: s.r}
+	return s
+}
+
+// SetReadLimit makes reads fail with a LimitError after n bytes, n <= 0 means unlimited
+func (s *CBORStream) SetReadLimit(n int64) {
+	s.r.limited.limit = n
+}
+
+type cborDecoder struct {
+	r *binaryReader
+}
+
+// head reads the initial byte and the argument of a data item,
+// indefinite is set for lengths encoded as 31
+func (d *cborDecoder) head() (major byte, info byte, arg uint64, indefinite bool, err error) {
+	c, err := d.r.ReadByte()
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	major, info = c>>5, c&0x1f
+	switch {
+	case info < 24:
+		return major, info, uint64(info), false, nil
+	case info <= 27:
+		arg, err = d.r.uint(1 << (info - 24))
+		return major, info, arg, false, err
+	case info == 31:
+		return major, info, 0, true, nil
+	}
+	return 0, 0, 0, false, fmt.Errorf("invalid CBOR additional information %d", info)
+}
+
+func (d *cborDecoder) next() (json.Token, int, error) {
+	major, info, arg, indefinite, err := d.head()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch major {
+	case cborUint:
+		return json.Number(strconv.FormatUint(arg, 10)), 0, nil
+	case cborNegint:
+		// -1 - arg doesn't fit int64 for arg >= 2^63
+		n := new(big.Int).SetUint64(arg)
+		return json.Number(n.Neg(n).Sub(n, big.NewInt(1)).String()), 0, nil
+	case cborBytes:
+		return TypedValue{Type: TypeBinary}, 0, d.skipString(cborBytes, arg, indefinite)
+	case cborText:
+		s, err := d.text(arg, indefinite)
+		return s, 0, err
+	case cborArray, cborMap:
+		if arg > math.MaxInt32 {
+			return nil, 0, fmt.Errorf("CBOR container of %d items is too long", arg)
+		}
+		length := int(arg)
+		if indefinite {
+			length = -1
+		}
+		if major == cborArray {
+			return json.Delim('['), length, nil
+		}
+		return json.Delim('{'), length, nil
+	case cborTag:
+		return d.tagged(arg)
+	}
+
+	switch {
+	case info == 20:
+		return false, 0, nil
+	case info == 21:
+		return true, 0, nil
+	case info == 22, info == 23: // null, undefined
+		return nil, 0, nil
+	case info == 25:
+		return halfFloat(uint16(arg)), 0, nil
+	case info == 26:
+		return float64(math.Float32frombits(uint32(arg))), 0, nil
+	case info == 27:
+		return math.Float64frombits(arg), 0, nil
+	case indefinite:
+		return nil, 0, fmt.Errorf("unexpected CBOR break")
+	}
+	// unassigned simple values
+	return TypedValue{Type: TypeUnknown}, 0, nil
+}
+
+// tagged reads the content of a tag
+func (d *cborDecoder) tagged(tag uint64) (json.Token, int, error) {
+	switch tag {
+	case cborSelfDescribed:
+		return d.next()
+	case 2, 3: // unsigned and negative bignums
+		major, _, arg, indefinite, err := d.head()
+		if err != nil {
+			return nil, 0, unexpectedEOF(err)
+		}
+		if major != cborBytes || indefinite {
+			return nil, 0, fmt.Errorf("invalid CBOR bignum")
+		}
+		b, err := d.r.readN(arg)
+		if err != nil {
+			return nil, 0, err
+		}
+		n := new(big.Int).SetBytes(b)
+		if tag == 3 {
+			n.Neg(n).Sub(n, big.NewInt(1))
+		}
+		return json.Number(n.String()), 0, nil
+	}
+
+	t, known := cborTagTypes[tag]
+	if !known {
+		t = TypeCBORTag
+	}
+	return TypedValue{Type: t}, 0, d.skipItem()
+}
+
+// text reads a text string, chunks of indefinite length strings are concatenated
+func (d *cborDecoder) text(n uint64, indefinite bool) (string, error) {
+	if !indefinite {
+		b, err := d.r.readN(n)
+		return string(b), err
+	}
+	var sb strings.Builder
+	for {
+		major, info, arg, chunkIndefinite, err := d.head()
+		if err != nil {
+			return "", unexpectedEOF(err)
+		}
+		if major == cborSimple && info == 31 {
+			return sb.String(), nil
+		}
+		if major != cborText || chunkIndefinite {
+			return "", fmt.Errorf("invalid chunk of CBOR text string")
+		}
+		b, err := d.r.readN(arg)
+		if err != nil {
+			return "", err
+		}
+		sb.Write(b)
+	}
+}
+
+// skipString skips a byte or text string
+func (d *cborDecoder) skipString(major byte, n uint64, indefinite bool) error {
+	if !indefinite {
+		return d.r.skip(n)
+	}
+	for {
+		chunkMajor, info, arg, chunkIndefinite, err := d.head()
+		if err != nil {
+			return unexpectedEOF(err)
+		}
+		if chunkMajor == cborSimple && info == 31 {
+			return nil
+		}
+		if chunkMajor != major || chunkIndefinite {
+			return fmt.Errorf("invalid chunk of CBOR string")
+		}
+		if err := d.r.skip(arg); err != nil {
+			return err
+		}
+	}
+}
+
+// skipItem skips a whole data item
+func (d *cborDecoder) skipItem() error {
+	major, _, arg, indefinite, err := d.head()
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	switch major {
+	case cborBytes, cborText:
+		return d.skipString(major, arg, indefinite)
+	case cborTag:
+		return d.skipItem()
+	case cborSimple:
+		if indefinite {
+			return fmt.Errorf("unexpected CBOR break")
+		}
+		return nil
+	case cborArray, cborMap:
+		items := arg
+		if major == cborMap {
+			items *= 2
+		}
+		for i := uint64(0); indefinite || i < items; i++ {
+			if indefinite {
+				ended, err := d.atEnd()
+				if err != nil {
+					return unexpectedEOF(err)
+				}
+				if ended {
+					return d.close()
+				}
+			}
+			if err := d.skipItem(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// key reads a text or integer key
+func (d *cborDecoder) key() (string, error) {
+	token, _, err := d.next()
+	if err != nil {
+		return "", err
+	}
+	switch t := token.(type) {
+	case string:
+		return t, nil
+	case json.Number:
+		return string(t), nil
+	}
+	return "", fmt.Errorf("unsupported CBOR map key %v", token)
+}
+
+func (d *cborDecoder) element() error {
+	return nil
+}
+
+func (d *cborDecoder) atEnd() (bool, error) {
+	b, err := d.r.Peek(1)
+	if err != nil {
+		return false, err
+	}
+	return b[0] == cborBreak, nil
+}
+
+func (d *cborDecoder) close() error {
+	_, err := d.r.ReadByte()
+	return err
+}
+
+// halfFloat converts an IEEE 754 half-precision float
+func halfFloat(h uint16) float64 {
+	exp := int(h>>10) & 0x1f
+	mant := float64(h & 0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		f = math.Inf(1)
+		if mant != 0 {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
@@ -0,0 +1,179 @@
+package jsontype_test
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/4nd3r5on/jsontype"
+)
+
+// mergeFormat merges the stream and the equivalent JSON, and compares the trees
+func mergeFormat(t *testing.T, s jsontype.Stream, equivalent string, opts ...jsontype.ParseOption) *jsontype.Merger {
+	t.Helper()
+	opts = append(opts, jsontype.WithLogger(slog.New(slog.DiscardHandler)))
+
+	merger, err := jsontype.MergeStream(jsontype.NewMerger([]string{}), "test", s, opts...)
+	if err != nil {
+		t.Fatalf("merge stream: %v", err)
+	}
+	expected, err := jsontype.MergeStream(jsontype.NewMerger([]string{}), "test",
+		jsontype.NewJSONStream(strings.NewReader(equivalent)), opts...)
+	if err != nil {
+		t.Fatalf("merge JSON: %v", err)
+	}
+	if got, want := canonicalMerger(merger), canonicalMerger(expected); got != want {
+		t.Errorf("trees differ\nGot:\n%s\nExpected:\n%s", got, want)
+	}
+	return merger
+}
+
+func TestYAMLStream(t *testing.T) {
+	const manifests = `
+apiVersion: v1
+kind: Service
+metadata: &meta
+  name: api
+  labels: {app: api}
+spec:
+  ports:
+    - port: 80
+      targetPort: 8080
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  <<: *meta
+  name: api-deployment
+  created: 2024-01-31T10:00:00Z
+spec:
+  replicas: 0x3
+  debug: ~
+  ignored: {a: 1}
+---
+`
+	s := jsontype.NewYAMLStream(strings.NewReader(manifests))
+	multi, err := s.MultiDocument()
+	if err != nil || !multi {
+		t.Fatalf("MultiDocument() = %v, %v, want true", multi, err)
+	}
+	mergeFormat(t, s, `
+{"apiVersion": "v1", "kind": "Service", "metadata": {"name": "api", "labels": {"app": "api"}},
+ "spec": {"ports": [{"port": 80, "targetPort": 8080}]}}
+{"apiVersion": "apps/v1", "kind": "Deployment",
+ "metadata": {"name": "api-deployment", "created": "2024-01-31T10:00:00Z", "labels": {"app": "api"}},
+ "spec": {"replicas": 3, "debug": null}}`,
+		jsontype.WithNDJSON(), jsontype.WithIgnoreObjects([]string{"spec", "ignored"}))
+
+	single := jsontype.NewYAMLStream(strings.NewReader("a: 1\n---\n"))
+	if multi, err := single.MultiDocument(); err != nil || multi {
+		t.Fatalf("MultiDocument() = %v, %v, want false", multi, err)
+	}
+
+	_, err = jsontype.MergeStream(nil, "test", jsontype.NewYAMLStream(strings.NewReader("a: &a {b: *a}\n")),
+		jsontype.WithLogger(slog.New(slog.DiscardHandler)))
+	if err == nil || !strings.Contains(err.Error(), "recursive alias") {
+		t.Errorf("recursive alias: got error %v", err)
+	}
+}
+
+func TestYAMLStream_AliasBomb(t *testing.T) {
+	// 10^7 values from a few hundred bytes
+	const bomb = `
+a: &a ["lol","lol","lol","lol","lol","lol","lol","lol","lol","lol"]
+b: &b [*a,*a,*a,*a,*a,*a,*a,*a,*a,*a]
+c: &c [*b,*b,*b,*b,*b,*b,*b,*b,*b,*b]
+d: &d [*c,*c,*c,*c,*c,*c,*c,*c,*c,*c]
+e: &e [*d,*d,*d,*d,*d,*d,*d,*d,*d,*d]
+f: &f [*e,*e,*e,*e,*e,*e,*e,*e,*e,*e]
+g: &g [*f,*f,*f,*f,*f,*f,*f,*f,*f,*f]
+`
+	_, err := jsontype.MergeStream(nil, "test", jsontype.NewYAMLStream(strings.NewReader(bomb)),
+		jsontype.WithLogger(slog.New(slog.DiscardHandler)))
+	if err == nil || !strings.Contains(err.Error(), "excessive aliasing") {
+		t.Errorf("expected excessive aliasing, got error %v", err)
+	}
+
+	// aliases used a few times are fine
+	const shared = `
+defaults: &defaults {retries: 3, timeout: 10}
+services:
+  - {name: a, <<: *defaults}
+  - {name: b, <<: *defaults}
+  - {name: c, opts: *defaults}
+`
+	if _, err := jsontype.MergeStream(nil, "test", jsontype.NewYAMLStream(strings.NewReader(shared)),
+		jsontype.WithLogger(slog.New(slog.DiscardHandler))); err != nil {
+		t.Errorf("shared anchors: %v", err)
+	}
+}
+
+func TestTOMLStream(t *testing.T) {
+	const config = `
+title = "example"
+big = 9007199254740993
+
+[owner]
+name = "Tom"
+dob = 1979-05-27T07:32:00-08:00
+
+[database]
+ports = [8000, 8001]
+enabled = true
+ratio = 0.5
+backup = 1979-05-27
+
+[[products]]
+name = "Hammer"
+sku = 738594937
+
+[[products]]
+name = "Nail"
+color = { name = "gray", hex = "aaa" }
+`
+	merger := mergeFormat(t, jsontype.NewTOMLStream(strings.NewReader(config)), `
+{"title": "example", "big": 9007199254740993,
+ "owner": {"name": "Tom", "dob": "1979-05-27T07:32:00-08:00"},
+ "database": {"ports": [8000, 8001], "enabled": true, "ratio": 0.5, "backup": "1979-05-27"},
+ "products": [{"name": "Hammer", "sku": 738594937}, {"name": "Nail", "color": {"name": "gray", "hex": "aaa"}}]}`)
+
+	if want := []string{"title", "big", "owner", "database", "products"}; !slices.Equal(merger.ChildrenKeys, want) {
+		t.Errorf("keys = %v, want document order %v", merger.ChildrenKeys, want)
+	}
+}
+
+func TestJSON5Stream(t *testing.T) {
+	const doc = `// settings
+{
+  /* block
+     comment */
+  name: 'editor',
+  "tabSize": 4,
+  $hex: 0xFF,
+  ratio: .5,
+  limit: +Infinity,
+  escaped: 'it\'s \x41é \
+continued',
+  list: [1, 2, 3,],
+  nested: {a: null, b: [{c: true,},],},
+}
+[1, 2] // a second top-level value`
+
+	s := jsontype.NewJSON5Stream(strings.NewReader(doc))
+	mergeFormat(t, s, `{
+  "name": "editor", "tabSize": 4, "$hex": 255, "ratio": 0.5, "limit": 1e300,
+  "escaped": "it's Aé continued", "list": [1, 2, 3], "nested": {"a": null, "b": [{"c": true}]}}`)
+	if !s.More() {
+		t.Fatal("More() = false before the second value")
+	}
+
+	for _, invalid := range []string{`{a: 1,,}`, `[,]`, `{"a" 1}`, `{a: 01x}`, `/* open`, `'line
+break'`, `[1 2]`, `{a: undefined}`} {
+		_, err := jsontype.MergeStream(nil, "test", jsontype.NewJSON5Stream(strings.NewReader(invalid)),
+			jsontype.WithLogger(slog.New(slog.DiscardHandler)))
+		if err == nil {
+			t.Errorf("%q: expected an error", invalid)
+		}
+	}
+}
@@ -0,0 +1,476 @@
+package jsontype
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+)
+
+// json5State is what a JSON5Stream expects next
+type json5State int
+
+const (
+	// a value, or the closing bracket in an array
+	json5Value json5State = iota
+	// a key or the closing brace
+	json5Key
+	// a comma or the closing delimiter
+	json5AfterValue
+)
+
+// JSON5Stream reads JSON5 and JSONC: JSON with comments, trailing commas, unquoted keys,
+// single-quoted strings, hexadecimal numbers, Infinity and NaN.
+// Like json.Decoder, it reads a sequence of top-level values
+type JSON5Stream struct {
+	r      *bufio.Reader
+	reader *limitedReader
+	// open containers, '{' or '['
+	stack []byte
+	state json5State
+}
+
+func NewJSON5Stream(r io.Reader) *JSON5Stream {
+	reader := &limitedReader{r: r, lines: newLineTracker()}
+	return &JSON5Stream{r: bufio.NewReader(reader), reader: reader}
+}
+
+// InputOffset returns the offset of the next unread byte
+func (s *JSON5Stream) InputOffset() int64 {
+	return s.reader.read - int64(s.r.Buffered())
+}
+
+func (s *JSON5Stream) locate(offset int64) (line, column int, snippet string) {
+	return s.reader.lines.locate(offset)
+}
+
+// SetReadLimit makes reads fail with a LimitError after n bytes, n <= 0 means unlimited
+func (s *JSON5Stream) SetReadLimit(n int64) {
+	s.reader.limit = n
+}
+
+func (s *JSON5Stream) Token() (json.Token, error) {
+	c, err := s.peek()
+	if err == io.EOF && len(s.stack) > 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch s.state {
+	case json5AfterValue:
+		if len(s.stack) == 0 {
+			// next top-level value
+			s.state = json5Value
+			break
+		}
+		if c == ',' {
+			s.r.ReadRune()
+			s.state = s.elementState()
+			return s.Token()
+		}
+		if c == closing(s.stack[len(s.stack)-1]) {
+			return s.close()
+		}
+		return nil, fmt.Errorf("invalid character %q after %s element", c, containerName(s.stack[len(s.stack)-1]))
+	case json5Key:
+		if c == '}' {
+			return s.close()
+		}
+		key, err := s.readKey(c)
+		if err != nil {
+			return nil, err
+		}
+		c, err := s.peek()
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if c != ':' {
+			return nil, fmt.Errorf("invalid character %q after object key %q", c, key)
+		}
+		s.r.ReadRune()
+		s.state = json5Value
+		return key, nil
+	}
+
+	if c == ']' && len(s.stack) > 0 && s.stack[len(s.stack)-1] == '[' {
+		return s.close()
+	}
+	return s.readValue(c)
+}
+
+// More reports if the current container has more elements,
+// or if there is another value at the top level
+func (s *JSON5Stream) More() bool {
+	c, err := s.peek()
+	if err != nil {
+		// errors other than EOF are returned by the next Token
+		return err != io.EOF
+	}
+	if len(s.stack) == 0 {
+		return true
+	}
+	if s.state == json5AfterValue && c == ',' {
+		s.r.ReadRune()
+		s.state = s.elementState()
+		if c, err = s.peek(); err != nil {
+			return true
+		}
+	}
+	return c != ']' && c != '}'
+}
+
+func (s *JSON5Stream) SkipValue() error {
+	return skipValue(s)
+}
+
+// elementState is the state after a comma
+func (s *JSON5Stream) elementState() json5State {
+	if s.stack[len(s.stack)-1] == '{' {
+		return json5Key
+	}
+	return json5Value
+}
+
+func (s *JSON5Stream) close() (json.Token, error) {
+	c, _, _ := s.r.ReadRune()
+	s.stack = s.stack[:len(s.stack)-1]
+	s.state = json5AfterValue
+	return json.Delim(c), nil
+}
+
+func closing(open byte) rune {
+	if open == '{' {
+		return '}'
+	}
+	return ']'
+}
+
+func containerName(open byte) string {
+	if open == '{' {
+		return "object"
+	}
+	return "array"
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// peek returns the next character skipping whitespace and comments without reading it
+func (s *JSON5Stream) peek() (rune, error) {
+	for {
+		c, _, err := s.r.ReadRune()
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case c == '\uFEFF' || unicode.IsSpace(c):
+			continue
+		case c == '/':
+			if err := s.skipComment(); err != nil {
+				return 0, err
+			}
+			continue
+		}
+		return c, s.r.UnreadRune()
+	}
+}
+
+// skipComment skips a // or /* */ comment, the first slash is already read
+func (s *JSON5Stream) skipComment() error {
+	c, _, err := s.r.ReadRune()
+	if err != nil {
+		return unexpectedEOF(err)
+	}
+	switch c {
+	case '/':
+		_, err := s.r.ReadString('\n')
+		if err == io.EOF {
+			return nil
+		}
+		return err
+	case '*':
+		var star bool
+		for {
+			c, _, err := s.r.ReadRune()
+			if err != nil {
+				return fmt.Errorf("unterminated comment: %w", unexpectedEOF(err))
+			}
+			if star && c == '/' {
+				return nil
+			}
+			star = c == '*'
+		}
+	}
+	return fmt.Errorf("invalid character %q after '/'", c)
+}
+
+func (s *JSON5Stream) readValue(c rune) (json.Token, error) {
+	switch {
+	case c == '{' || c == '[':
+		s.r.ReadRune()
+		s.stack = append(s.stack, byte(c))
+		s.state = json5Value
+		if c == '{' {
+			s.state = json5Key
+		}
+		return json.Delim(c), nil
+	case c == '"' || c == '\'':
+		str, err := s.readString()
+		if err != nil {
+			return nil, err
+		}
+		s.state = json5AfterValue
+		return str, nil
+	case c == '-' || c == '+' || c == '.' || c >= '0' && c <= '9':
+		number, err := s.readNumber()
+		if err != nil {
+			return nil, err
+		}
+		s.state = json5AfterValue
+		return number, nil
+	case isIdentifierStart(c):
+		name, err := s.readIdentifier()
+		if err != nil {
+			return nil, err
+		}
+		var token json.Token
+		switch name {
+		case "null":
+			token = nil
+		case "true":
+			token = true
+		case "false":
+			token = false
+		case "Infinity":
+			token = math.Inf(1)
+		case "NaN":
+			token = math.NaN()
+		default:
+			return nil, fmt.Errorf("invalid literal %q", name)
+		}
+		s.state = json5AfterValue
+		return token, nil
+	}
+	return nil, fmt.Errorf("invalid character %q looking for beginning of value", c)
+}
+
+// readKey reads a quoted key or an identifier
+func (s *JSON5Stream) readKey(c rune) (string, error) {
+	switch {
+	case c == '"' || c == '\'':
+		return s.readString()
+	case isIdentifierStart(c) || c == '\\':
+		return s.readIdentifier()
+	}
+	return "", fmt.Errorf("invalid character %q looking for beginning of object key", c)
+}
+
+func isIdentifierStart(c rune) bool {
+	return c == '$' || c == '_' || unicode.IsLetter(c) || unicode.Is(unicode.Nl, c)
+}
+
+func isIdentifierPart(c rune) bool {
+	return isIdentifierStart(c) || unicode.In(c, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc) ||
+		c == '\u200C' || c == '\u200D'
+}
+
+// readIdentifier reads an ECMAScript identifier name, \uXXXX escapes are decoded
+func (s *JSON5Stream) readIdentifier() (string, error) {
+	var b strings.Builder
+	for {
+		c, _, err := s.r.ReadRune()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if c == '\\' {
+			if next, _, err := s.r.ReadRune(); err != nil || next != 'u' {
+				return "", fmt.Errorf("invalid escape in identifier %q", b.String())
+			}
+			if c, err = s.readHex(4); err != nil {
+				return "", err
+			}
+		} else if !isIdentifierPart(c) {
+			s.r.UnreadRune()
+			break
+		}
+		if b.Len() == 0 && !isIdentifierStart(c) {
+			return "", fmt.Errorf("invalid character %q at the beginning of identifier", c)
+		}
+		b.WriteRune(c)
+	}
+	return b.String(), nil
+}
+
+// readString reads a string quoted with the next character
+func (s *JSON5Stream) readString() (string, error) {
+	quote, _, _ := s.r.ReadRune()
+	var b strings.Builder
+	for {
+		c, _, err := s.r.ReadRune()
+		if err != nil {
+			return "", fmt.Errorf("unterminated string: %w", unexpectedEOF(err))
+		}
+		switch c {
+		case quote:
+			return b.String(), nil
+		case '\n', '\r':
+			return "", errors.New("invalid line break in string")
+		case '\\':
+			if err := s.readEscape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteRune(c)
+		}
+	}
+}
+
+// readEscape decodes an escape sequence, the backslash is already read
+func (s *JSON5Stream) readEscape(b *strings.Builder) error {
+	c, _, err := s.r.ReadRune()
+	if err != nil {
+		return fmt.Errorf("unterminated string: %w", unexpectedEOF(err))
+	}
+	switch c {
+	case 'b':
+		b.WriteByte('\b')
+	case 'f':
+		b.WriteByte('\f')
+	case 'n':
+		b.WriteByte('\n')
+	case 'r':
+		b.WriteByte('\r')
+	case 't':
+		b.WriteByte('\t')
+	case 'v':
+		b.WriteByte('\v')
+	case '0':
+		if next, err := s.r.Peek(1); err == nil && next[0] >= '0' && next[0] <= '9' {
+			return errors.New("invalid octal escape in string")
+		}
+		b.WriteByte(0)
+	case 'x':
+		r, err := s.readHex(2)
+		if err != nil {
+			return err
+		}
+		b.WriteRune(r)
+	case 'u':
+		r, err := s.readHex(4)
+		if err != nil {
+			return err
+		}
+		if utf16.IsSurrogate(r) {
+			// the low surrogate must follow as another \u escape
+			if next, err := s.r.Peek(2); err == nil && string(next) == `\u` {
+				s.r.Discard(2)
+				low, err := s.readHex(4)
+				if err != nil {
+					return err
+				}
+				r = utf16.DecodeRune(r, low)
+			} else {
+				r = unicode.ReplacementChar
+			}
+		}
+		b.WriteRune(r)
+	case '\r':
+		// line continuation
+		if next, err := s.r.Peek(1); err == nil && next[0] == '\n' {
+			s.r.Discard(1)
+		}
+	case '\n', '\u2028', '\u2029':
+		// line continuation
+	default:
+		if c >= '1' && c <= '9' {
+			return fmt.Errorf("invalid escape '\\%c' in string", c)
+		}
+		b.WriteRune(c)
+	}
+	return nil
+}
+
+// readHex reads a character code of n hexadecimal digits
+func (s *JSON5Stream) readHex(n int) (rune, error) {
+	digits := make([]byte, n)
+	if _, err := io.ReadFull(s.r, digits); err != nil {
+		return 0, fmt.Errorf("invalid escape: %w", unexpectedEOF(err))
+	}
+	code, err := strconv.ParseUint(string(digits), 16, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid escape %q", digits)
+	}
+	return rune(code), nil
+}
+
+// readNumber reads a decimal or hexadecimal number with an optional sign, Infinity or NaN.
+// Numbers are returned as json.Number, Infinity and NaN as float64
+func (s *JSON5Stream) readNumber() (json.Token, error) {
+	var b strings.Builder
+	for {
+		c, _, err := s.r.ReadRune()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		isNumberPart := c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' ||
+			c == '.' || c == '+' || c == '-'
+		if !isNumberPart {
+			s.r.UnreadRune()
+			break
+		}
+		b.WriteRune(c)
+	}
+
+	literal := b.String()
+	unsigned := strings.TrimLeft(literal, "+-")
+	if len(literal)-len(unsigned) > 1 {
+		return 0, fmt.Errorf("invalid number %q", literal)
+	}
+	sign := ""
+	if strings.HasPrefix(literal, "-") {
+		sign = "-"
+	}
+
+	switch {
+	case unsigned == "Infinity":
+		if sign != "" {
+			return math.Inf(-1), nil
+		}
+		return math.Inf(1), nil
+	case unsigned == "NaN":
+		return math.NaN(), nil
+	case strings.HasPrefix(unsigned, "0x"), strings.HasPrefix(unsigned, "0X"):
+		u, err := strconv.ParseUint(unsigned[2:], 16, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid number %q", literal)
+		}
+		return json.Number(sign + strconv.FormatUint(u, 10)), nil
+	}
+	// ParseFloat accepts forms JSON5 doesn't: hexadecimal floats, underscores, "Inf"
+	if strings.ContainsAny(unsigned, "_xXpPiInN") || unsigned == "" {
+		return 0, fmt.Errorf("invalid number %q", literal)
+	}
+	// out of range numbers are kept as written
+	if _, err := strconv.ParseFloat(unsigned, 64); err != nil && !errors.Is(err, strconv.ErrRange) {
+		return 0, fmt.Errorf("invalid number %q", literal)
+	}
+	return json.Number(sign + unsigned), nil
+}
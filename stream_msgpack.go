@@ -0,0 +1,154 @@
+package jsontype
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// msgpackTimestamp is the extension type of MessagePack timestamps
+const msgpackTimestamp = -1
+
+// MsgPackStream reads a sequence of MessagePack values.
+// Binary data, timestamps and extensions are returned as TypedValue tokens,
+// integer map keys are read as strings
+type MsgPackStream struct {
+	binaryStream
+}
+
+func NewMsgPackStream(r io.Reader) *MsgPackStream {
+	s := &MsgPackStream{}
+	s.r = newBinaryReader(r)
+	s.decoder = &msgpackDecoder{r: s.r}
+	return s
+}
+
+// SetReadLimit makes reads fail with a LimitError after n bytes, n <= 0 means unlimited
+func (s *MsgPackStream) SetReadLimit(n int64) {
+	s.r.limited.limit = n
+}
+
+type msgpackDecoder struct {
+	r *binaryReader
+}
+
+func (d *msgpackDecoder) next() (json.Token, int, error) {
+	c, err := d.r.ReadByte()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch {
+	case c <= 0x7f: // positive fixint
+		return json.Number(strconv.Itoa(int(c))), 0, nil
+	case c >= 0xe0: // negative fixint
+		return json.Number(strconv.Itoa(int(int8(c)))), 0, nil
+	case c <= 0x8f: // fixmap
+		return json.Delim('{'), int(c & 0x0f), nil
+	case c <= 0x9f: // fixarray
+		return json.Delim('['), int(c & 0x0f), nil
+	case c <= 0xbf: // fixstr
+		return d.str(uint64(c & 0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, 0, nil
+	case 0xc2:
+		return false, 0, nil
+	case 0xc3:
+		return true, 0, nil
+	case 0xc4, 0xc5, 0xc6: // bin 8, 16, 32
+		n, err := d.r.uint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, 0, err
+		}
+		return TypedValue{Type: TypeBinary}, 0, d.r.skip(n)
+	case 0xc7, 0xc8, 0xc9: // ext 8, 16, 32
+		n, err := d.r.uint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, 0, err
+		}
+		return d.ext(n)
+	case 0xca:
+		bits, err := d.r.uint(4)
+		return float64(math.Float32frombits(uint32(bits))), 0, err
+	case 0xcb:
+		bits, err := d.r.uint(8)
+		return math.Float64frombits(bits), 0, err
+	case 0xcc, 0xcd, 0xce, 0xcf: // uint 8, 16, 32, 64
+		v, err := d.r.uint(1 << (c - 0xcc))
+		return json.Number(strconv.FormatUint(v, 10)), 0, err
+	case 0xd0, 0xd1, 0xd2, 0xd3: // int 8, 16, 32, 64
+		size := 1 << (c - 0xd0)
+		v, err := d.r.uint(size)
+		// sign extension
+		shift := 64 - 8*size
+		return json.Number(strconv.FormatInt(int64(v<<shift)>>shift, 10)), 0, err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8: // fixext 1, 2, 4, 8, 16
+		return d.ext(1 << (c - 0xd4))
+	case 0xd9, 0xda, 0xdb: // str 8, 16, 32
+		n, err := d.r.uint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, 0, err
+		}
+		return d.str(n)
+	case 0xdc, 0xdd: // array 16, 32
+		n, err := d.r.uint(2 << (c - 0xdc))
+		return json.Delim('['), int(n), err
+	case 0xde, 0xdf: // map 16, 32
+		n, err := d.r.uint(2 << (c - 0xde))
+		return json.Delim('{'), int(n), err
+	}
+	return nil, 0, fmt.Errorf("invalid MessagePack type 0x%02x", c)
+}
+
+func (d *msgpackDecoder) str(n uint64) (json.Token, int, error) {
+	b, err := d.r.readN(n)
+	if err != nil {
+		return nil, 0, err
+	}
+	return string(b), 0, nil
+}
+
+// ext skips extension data of n bytes after its type
+func (d *msgpackDecoder) ext(n uint64) (json.Token, int, error) {
+	extType, err := d.r.ReadByte()
+	if err != nil {
+		return nil, 0, unexpectedEOF(err)
+	}
+	t := TypeExtension
+	if int8(extType) == msgpackTimestamp {
+		t = TypeDateTime
+	}
+	return TypedValue{Type: t}, 0, d.r.skip(n)
+}
+
+// key reads a string or integer key
+func (d *msgpackDecoder) key() (string, error) {
+	token, _, err := d.next()
+	if err != nil {
+		return "", err
+	}
+	switch t := token.(type) {
+	case string:
+		return t, nil
+	case json.Number:
+		return string(t), nil
+	}
+	return "", fmt.Errorf("unsupported MessagePack map key %v", token)
+}
+
+func (d *msgpackDecoder) element() error {
+	return nil
+}
+
+func (d *msgpackDecoder) atEnd() (bool, error) {
+	return false, nil
+}
+
+func (d *msgpackDecoder) close() error {
+	return nil
+}
@@ -0,0 +1,134 @@
+package jsontype
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// TOMLStream reads a TOML document as a single object.
+// Keys keep the document order, datetimes are read as strings
+// in their TOML form to be analyzed by detectors
+type TOMLStream struct {
+	treeStream
+	reader *limitedReader
+	read   bool
+	// position of every key path in the document, array indices aren't included
+	order map[string]int
+}
+
+func NewTOMLStream(r io.Reader) *TOMLStream {
+	s := &TOMLStream{reader: &limitedReader{r: r}}
+	s.treeStream = treeStream{next: s.nextDocument, describe: s.describe}
+	return s
+}
+
+// SetReadLimit makes reads fail with a LimitError after n bytes, n <= 0 means unlimited
+func (s *TOMLStream) SetReadLimit(n int64) {
+	s.reader.limit = n
+}
+
+// tomlValue is a value with its key path
+type tomlValue struct {
+	value any
+	path  string
+}
+
+func (s *TOMLStream) nextDocument() (any, error) {
+	if s.read {
+		return nil, io.EOF
+	}
+	s.read = true
+
+	var doc map[string]any
+	meta, err := toml.NewDecoder(s.reader).Decode(&doc)
+	if err != nil {
+		return nil, err
+	}
+	s.order = make(map[string]int)
+	for i, key := range meta.Keys() {
+		path := strings.Join(key, "\x00")
+		if _, exists := s.order[path]; !exists {
+			s.order[path] = i
+		}
+	}
+	return tomlValue{value: doc}, nil
+}
+
+func (s *TOMLStream) describe(value any) (json.Token, []treeEntry, error) {
+	v := value.(tomlValue)
+	switch t := v.value.(type) {
+	case map[string]any:
+		paths := make(map[string]string, len(t))
+		for key := range t {
+			paths[key] = key
+			if v.path != "" {
+				paths[key] = v.path + "\x00" + key
+			}
+		}
+		keys := slices.SortedFunc(maps.Keys(t), func(a, b string) int {
+			return cmp.Or(s.compareOrder(paths[a], paths[b]), cmp.Compare(a, b))
+		})
+		entries := make([]treeEntry, len(keys))
+		for i, key := range keys {
+			entries[i] = treeEntry{key: key, value: tomlValue{value: t[key], path: paths[key]}}
+		}
+		return json.Delim('{'), entries, nil
+	case []map[string]any:
+		entries := make([]treeEntry, len(t))
+		for i, table := range t {
+			entries[i] = treeEntry{value: tomlValue{value: table, path: v.path}}
+		}
+		return json.Delim('['), entries, nil
+	case []any:
+		entries := make([]treeEntry, len(t))
+		for i, element := range t {
+			entries[i] = treeEntry{value: tomlValue{value: element, path: v.path}}
+		}
+		return json.Delim('['), entries, nil
+	case string, bool, float64:
+		return t, nil, nil
+	case int64:
+		return json.Number(strconv.FormatInt(t, 10)), nil, nil
+	case time.Time:
+		return formatTOMLTime(t), nil, nil
+	}
+	return nil, nil, fmt.Errorf("unexpected TOML value %T", v.value)
+}
+
+// compareOrder orders key paths by their first appearance in the document,
+// keys the decoder didn't report go last
+func (s *TOMLStream) compareOrder(a, b string) int {
+	i, aKnown := s.order[a]
+	j, bKnown := s.order[b]
+	switch {
+	case aKnown && bKnown:
+		return cmp.Compare(i, j)
+	case aKnown:
+		return -1
+	case bKnown:
+		return 1
+	}
+	return 0
+}
+
+// formatTOMLTime formats local dates, times and datetimes without a time zone
+func formatTOMLTime(t time.Time) string {
+	switch t.Location().String() {
+	case "datetime-local":
+		return t.Format("2006-01-02T15:04:05.999999999")
+	case "date-local":
+		return t.Format(time.DateOnly)
+	case "time-local":
+		return t.Format("15:04:05.999999999")
+	}
+	return t.Format(time.RFC3339Nano)
+}
@@ -0,0 +1,138 @@
+package jsontype
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// treeEntry is a child of a decoded container, key is empty for array elements
+type treeEntry struct {
+	key   string
+	value any
+}
+
+// treeFrame is an open container of a treeStream
+type treeFrame struct {
+	entries []treeEntry
+	object  bool
+	i       int
+}
+
+// treeStream emits tokens of documents decoded by another format.
+// Containers are expanded only once their tokens are read, so aliases
+// and other shared values cost nothing until they are parsed
+type treeStream struct {
+	// next returns the next document, io.EOF at the end
+	next func() (any, error)
+	// describe returns the token of a value: a scalar or '{' / '[' with the children
+	describe func(v any) (json.Token, []treeEntry, error)
+
+	stack      []*treeFrame
+	pending    any
+	hasPending bool
+	// document read by More
+	lookahead    any
+	hasLookahead bool
+	err          error
+}
+
+// document moves the next document to pending
+func (s *treeStream) document() error {
+	if s.hasLookahead {
+		s.pending, s.hasPending = s.lookahead, true
+		s.lookahead, s.hasLookahead = nil, false
+		return nil
+	}
+	if s.err != nil {
+		return s.err
+	}
+	doc, err := s.next()
+	if err != nil {
+		s.err = err
+		return err
+	}
+	s.pending, s.hasPending = doc, true
+	return nil
+}
+
+func (s *treeStream) Token() (json.Token, error) {
+	if !s.hasPending {
+		if len(s.stack) == 0 {
+			if err := s.document(); err != nil {
+				return nil, err
+			}
+		} else {
+			top := s.stack[len(s.stack)-1]
+			if top.i == len(top.entries) {
+				s.stack = s.stack[:len(s.stack)-1]
+				if top.object {
+					return json.Delim('}'), nil
+				}
+				return json.Delim(']'), nil
+			}
+			entry := top.entries[top.i]
+			top.i++
+			s.pending, s.hasPending = entry.value, true
+			if top.object {
+				return entry.key, nil
+			}
+		}
+	}
+
+	v := s.pending
+	s.pending, s.hasPending = nil, false
+	token, entries, err := s.describe(v)
+	if err != nil {
+		return nil, err
+	}
+	if d, isDelim := token.(json.Delim); isDelim {
+		s.stack = append(s.stack, &treeFrame{entries: entries, object: d == '{'})
+	}
+	return token, nil
+}
+
+// More reports if the current container has more elements,
+// or if there is another document at the top level
+func (s *treeStream) More() bool {
+	if s.hasPending {
+		return true
+	}
+	if len(s.stack) > 0 {
+		top := s.stack[len(s.stack)-1]
+		return top.i < len(top.entries)
+	}
+	if s.hasLookahead {
+		return true
+	}
+	if s.err != nil {
+		return false
+	}
+	doc, err := s.next()
+	if err != nil {
+		s.err = err
+		// errors other than EOF are returned by the next Token
+		return err != io.EOF
+	}
+	s.lookahead, s.hasLookahead = doc, true
+	return true
+}
+
+func (s *treeStream) SkipValue() error {
+	switch {
+	case s.hasPending:
+		s.pending, s.hasPending = nil, false
+		return nil
+	case len(s.stack) == 0:
+		if err := s.document(); err != nil {
+			return err
+		}
+		s.pending, s.hasPending = nil, false
+		return nil
+	}
+	top := s.stack[len(s.stack)-1]
+	if !top.object && top.i < len(top.entries) {
+		top.i++
+		return nil
+	}
+	return skipValue(s)
+}
@@ -0,0 +1,285 @@
+package jsontype
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAMLStream reads a stream of YAML documents, every document is a top-level value
+// like a value of an NDJSON stream. Anchors and merge keys (<<) are expanded,
+// timestamps and binary scalars are read as strings to be analyzed by detectors
+type YAMLStream struct {
+	treeStream
+	decoder *yaml.Decoder
+	reader  *limitedReader
+	// documents decoded by MultiDocument
+	queue []*yaml.Node
+	// aliases expanded in the current document
+	expansion yamlExpansion
+}
+
+func NewYAMLStream(r io.Reader) *YAMLStream {
+	reader := &limitedReader{r: r}
+	s := &YAMLStream{decoder: yaml.NewDecoder(reader), reader: reader}
+	s.treeStream = treeStream{next: s.nextDocument, describe: s.describe}
+	return s
+}
+
+// SetReadLimit makes reads fail with a LimitError after n bytes, n <= 0 means unlimited
+func (s *YAMLStream) SetReadLimit(n int64) {
+	s.reader.limit = n
+}
+
+// MultiDocument reports if the stream has more than one document.
+// Call it before reading tokens, e.g. to parse documents as records (WithNDJSON)
+func (s *YAMLStream) MultiDocument() (bool, error) {
+	for len(s.queue) < 2 {
+		doc, err := s.decode()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return false, err
+		}
+		s.queue = append(s.queue, doc)
+	}
+	return len(s.queue) > 1, nil
+}
+
+func (s *YAMLStream) nextDocument() (any, error) {
+	s.expansion = yamlExpansion{}
+	if len(s.queue) > 0 {
+		doc := s.queue[0]
+		s.queue = s.queue[1:]
+		return yamlValue{node: doc}, nil
+	}
+	doc, err := s.decode()
+	if err != nil {
+		return nil, err
+	}
+	return yamlValue{node: doc}, nil
+}
+
+// decode returns the next non-empty document
+func (s *YAMLStream) decode() (*yaml.Node, error) {
+	for {
+		var doc yaml.Node
+		if err := s.decoder.Decode(&doc); err != nil {
+			return nil, err
+		}
+		// "---" without content, e.g. at the end of Kubernetes manifests
+		if len(doc.Content) == 0 {
+			continue
+		}
+		if n := doc.Content[0]; n.Kind == yaml.ScalarNode && n.Tag == "!!null" && n.Value == "" {
+			continue
+		}
+		return doc.Content[0], nil
+	}
+}
+
+// describe is describeYAML failing on documents expanding too many aliases
+func (s *YAMLStream) describe(value any) (json.Token, []treeEntry, error) {
+	v := value.(yamlValue)
+	if err := s.expansion.add(v); err != nil {
+		return nil, nil, err
+	}
+	return describeYAML(v)
+}
+
+// Bounds of the alias expansion ratio, the same as yaml.v3 uses when decoding into Go values
+const (
+	yamlAliasRatioLow  = 400000
+	yamlAliasRatioHigh = 4000000
+)
+
+// yamlExpansion counts values of a document, so a few bytes of nested aliases
+// (a "billion laughs" document) can't expand into billions of values
+type yamlExpansion struct {
+	values int
+	// values reached through aliases
+	aliased int
+}
+
+func (e *yamlExpansion) add(v yamlValue) error {
+	e.values++
+	if v.anchors != nil || v.node.Kind == yaml.AliasNode {
+		e.aliased++
+	}
+	if e.aliased > 100 && e.values > 1000 && float64(e.aliased)/float64(e.values) > allowedYAMLAliasRatio(e.values) {
+		return fmt.Errorf("line %d: document contains excessive aliasing", v.node.Line)
+	}
+	return nil
+}
+
+// allowedYAMLAliasRatio allows most values of small documents to come from aliases
+// and scales down to 10% for large ones
+func allowedYAMLAliasRatio(values int) float64 {
+	switch {
+	case values <= yamlAliasRatioLow:
+		return 0.99
+	case values >= yamlAliasRatioHigh:
+		return 0.10
+	default:
+		return 0.99 - 0.89*float64(values-yamlAliasRatioLow)/float64(yamlAliasRatioHigh-yamlAliasRatioLow)
+	}
+}
+
+// yamlAnchors are anchored nodes being expanded, used to detect recursive aliases
+type yamlAnchors struct {
+	node *yaml.Node
+	next *yamlAnchors
+}
+
+func (a *yamlAnchors) contains(node *yaml.Node) bool {
+	for ; a != nil; a = a.next {
+		if a.node == node {
+			return true
+		}
+	}
+	return false
+}
+
+// yamlValue is a node with the anchors it is nested in
+type yamlValue struct {
+	node    *yaml.Node
+	anchors *yamlAnchors
+}
+
+// resolve follows aliases
+func (v yamlValue) resolve() (yamlValue, error) {
+	for v.node.Kind == yaml.AliasNode {
+		target := v.node.Alias
+		if target == nil {
+			return v, fmt.Errorf("line %d: unknown anchor %q", v.node.Line, v.node.Value)
+		}
+		if v.anchors.contains(target) {
+			return v, fmt.Errorf("line %d: recursive alias %q", v.node.Line, v.node.Value)
+		}
+		v = yamlValue{node: target, anchors: &yamlAnchors{node: target, next: v.anchors}}
+	}
+	return v, nil
+}
+
+func describeYAML(value any) (json.Token, []treeEntry, error) {
+	v, err := value.(yamlValue).resolve()
+	if err != nil {
+		return nil, nil, err
+	}
+	node := v.node
+
+	switch node.Kind {
+	case yaml.SequenceNode:
+		entries := make([]treeEntry, len(node.Content))
+		for i, child := range node.Content {
+			entries[i] = treeEntry{value: yamlValue{node: child, anchors: v.anchors}}
+		}
+		return json.Delim('['), entries, nil
+	case yaml.MappingNode:
+		entries, err := yamlMappingEntries(v, make(map[string]bool))
+		if err != nil {
+			return nil, nil, err
+		}
+		return json.Delim('{'), entries, nil
+	case yaml.ScalarNode:
+		token, err := yamlScalar(node)
+		return token, nil, err
+	}
+	return nil, nil, fmt.Errorf("line %d: unexpected YAML node", node.Line)
+}
+
+// yamlMappingEntries returns fields of the mapping skipping the seen keys.
+// Fields set explicitly override fields of merge keys, earlier merged mappings override later ones
+func yamlMappingEntries(v yamlValue, seen map[string]bool) ([]treeEntry, error) {
+	content := v.node.Content
+	var merges []yamlValue
+	entries := make([]treeEntry, 0, len(content)/2)
+	for i := 0; i+1 < len(content); i += 2 {
+		key, err := yamlValue{node: content[i], anchors: v.anchors}.resolve()
+		if err != nil {
+			return nil, err
+		}
+		value := yamlValue{node: content[i+1], anchors: v.anchors}
+		if key.node.Kind == yaml.ScalarNode && key.node.Tag == "!!merge" {
+			merges = append(merges, value)
+			continue
+		}
+		if key.node.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("line %d: only scalar keys are supported", key.node.Line)
+		}
+		if seen[key.node.Value] {
+			continue
+		}
+		seen[key.node.Value] = true
+		entries = append(entries, treeEntry{key: key.node.Value, value: value})
+	}
+
+	for _, merge := range merges {
+		merge, err := merge.resolve()
+		if err != nil {
+			return nil, err
+		}
+		sources := []yamlValue{merge}
+		if merge.node.Kind == yaml.SequenceNode {
+			sources = sources[:0]
+			for _, child := range merge.node.Content {
+				sources = append(sources, yamlValue{node: child, anchors: merge.anchors})
+			}
+		}
+		for _, source := range sources {
+			source, err := source.resolve()
+			if err != nil {
+				return nil, err
+			}
+			if source.node.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("line %d: merge key value must be a mapping", source.node.Line)
+			}
+			merged, err := yamlMappingEntries(source, seen)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, merged...)
+		}
+	}
+	return entries, nil
+}
+
+// yamlScalar returns the token of a scalar, numbers are returned as json.Number to keep precision
+func yamlScalar(node *yaml.Node) (json.Token, error) {
+	switch node.ShortTag() {
+	case "!!null":
+		return nil, nil
+	case "!!bool":
+		var b bool
+		if err := node.Decode(&b); err != nil {
+			return nil, err
+		}
+		return b, nil
+	case "!!int":
+		var i int64
+		if err := node.Decode(&i); err == nil {
+			return json.Number(strconv.FormatInt(i, 10)), nil
+		}
+		var u uint64
+		if err := node.Decode(&u); err == nil {
+			return json.Number(strconv.FormatUint(u, 10)), nil
+		}
+		fallthrough
+	case "!!float":
+		var f float64
+		if err := node.Decode(&f); err != nil {
+			return nil, err
+		}
+		if isDecimalLiteral(node.Value) {
+			return json.Number(node.Value), nil
+		}
+		// .inf, .nan and other spellings
+		return f, nil
+	}
+	// strings, timestamps, binary and custom tags
+	return node.Value, nil
+}
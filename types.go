@@ -1,5 +1,7 @@
 package jsontype
 
+import "strings"
+
 type DetectedType string
 
 const (
@@ -9,6 +11,8 @@ const (
 	TypeBool    DetectedType = "bool"
 	TypeInt32   DetectedType = "int32"
 	TypeInt64   DetectedType = "int64"
+	TypeUint64  DetectedType = "uint64" // integers above int64
+	TypeBigInt  DetectedType = "bigint" // integers beyond 64 bits
 	TypeFloat64 DetectedType = "float64"
 	// Containers
 	TypeObj    DetectedType = "object"
@@ -25,6 +29,14 @@ const (
 	TypeEmail           DetectedType = "string-email"            // admin@email.com
 	TypePhone           DetectedType = "string-phone"            // +380661153394
 
+	// Dates and times
+	TypeDateTimeRFC3339 DetectedType = "string-datetime-rfc3339" // 2024-01-31T10:00:00Z
+	TypeDateTimeISO8601 DetectedType = "string-datetime-iso8601" // 2024-01-31T10:00:00 (no time zone)
+	TypeDateTimeRFC1123 DetectedType = "string-datetime-rfc1123" // Wed, 31 Jan 2024 10:00:00 GMT
+	TypeDate            DetectedType = "string-date"             // 2024-01-31
+	TypeTime            DetectedType = "string-time"             // 10:00:00
+	TypeDuration        DetectedType = "string-duration"         // PT5M
+
 	// Web
 	TypeLink   DetectedType = "string-link"   // https://google.com or google.com/search
 	TypeDomain DetectedType = "string-domain" // google.com
@@ -45,6 +57,68 @@ const (
 	TypeMAC          DetectedType = "string-mac"            // 9e:3b:74:a1:5f:c2
 )
 
+// Extended number detection/analysis (opt-in)
+// Detection code is at ./detect_num_type.go
+const (
+	// Integers in a plausible range of unix timestamps (years 2000-2100)
+	TypeUnixSeconds DetectedType = "int-unix-seconds" // 1718000000
+	TypeUnixMillis  DetectedType = "int-unix-millis"  // 1718000000000
+	TypeUnixMicros  DetectedType = "int-unix-micros"  // 1718000000000000
+	TypeUnixNanos   DetectedType = "int-unix-nanos"   // 1718000000000000000
+)
+
+// Types of binary formats (MessagePack, CBOR, BSON) that have no JSON equivalent.
+// Streams return them as TypedValue tokens, generators render them like their JSON equivalents
+const (
+	TypeBinary        DetectedType = "binary"          // MessagePack bin, CBOR byte string, BSON binary
+	TypeDateTime      DetectedType = "datetime"        // MessagePack timestamp, CBOR tags 0 and 1, BSON UTC datetime
+	TypeExtension     DetectedType = "msgpack-ext"     // MessagePack application-specific extension
+	TypeCBORTag       DetectedType = "cbor-tag"        // CBOR value with a tag that isn't recognized
+	TypeObjectID      DetectedType = "bson-objectid"   // BSON ObjectId
+	TypeDecimal128    DetectedType = "bson-decimal128" // BSON Decimal128
+	TypeBSONTimestamp DetectedType = "bson-timestamp"  // BSON internal timestamp
+	TypeRegex         DetectedType = "bson-regex"      // BSON regular expression
+)
+
+// jsonEquivalents are the types values of binary formats usually have once converted to JSON
+var jsonEquivalents = map[DetectedType]DetectedType{
+	TypeBinary:        TypeBase64Std,
+	TypeDateTime:      TypeDateTimeRFC3339,
+	TypeExtension:     TypeBase64Std,
+	TypeCBORTag:       TypeUnknown,
+	TypeObjectID:      TypeHEX,
+	TypeDecimal128:    TypeString,
+	TypeBSONTimestamp: TypeInt64,
+	TypeRegex:         TypeString,
+}
+
+// JSONEquivalentType returns the type of the value converted to JSON,
+// e.g. base64 strings for binary data. Types of JSON values are returned as is
+func JSONEquivalentType(t DetectedType) DetectedType {
+	if equivalent, exists := jsonEquivalents[t]; exists {
+		return equivalent
+	}
+	return t
+}
+
+// jsonTypes replaces types of binary formats in the counters with their JSON equivalents
+func jsonTypes(types map[DetectedType]int) map[DetectedType]int {
+	var converted map[DetectedType]int
+	for t := range types {
+		if _, exists := jsonEquivalents[t]; exists {
+			converted = make(map[DetectedType]int, len(types))
+			break
+		}
+	}
+	if converted == nil {
+		return types
+	}
+	for t, n := range types {
+		converted[JSONEquivalentType(t)] += n
+	}
+	return converted
+}
+
 // FieldInfo represents a single field/path in the JSON structure
 // It serves only for a run through a single file (since)
 type FieldInfo struct {
@@ -53,6 +127,10 @@ type FieldInfo struct {
 	Path []string
 	// If container -- will contain one of the following types: TypeObj, TypeObjInt, TypeObjArray
 	Type DetectedType
+	// Digits of a number written with a fraction or an exponent
+	Decimal DecimalInfo
+	// Integer type (int32 or int64) of a number detected as a unix timestamp
+	IntType DetectedType
 
 	// Container-specific info
 	Children []*FieldInfo // Ordered children for objects/arrays
@@ -68,15 +146,36 @@ func IsContainerType(t DetectedType) bool {
 	return false
 }
 
-// IsMixedContainer returns true if container has different types of children elements
-func IsMixedContainer(field *FieldInfo) bool {
-	if len(field.Children) < 1 {
-		return false
+// IsIntegerType returns true for int32, int64, uint64, bigint and extended integer types
+func IsIntegerType(t DetectedType) bool {
+	switch t {
+	case TypeInt32, TypeInt64, TypeUint64, TypeBigInt:
+		return true
 	}
+	return strings.HasPrefix(string(t), "int-")
+}
 
-	firstType := field.Children[0].Type
+// IsNumberType returns true for integer and floating point types
+func IsNumberType(t DetectedType) bool {
+	return t == TypeFloat64 || IsIntegerType(t)
+}
+
+// IsStringType returns true for TypeString and all extended string types
+func IsStringType(t DetectedType) bool {
+	return t == TypeString || strings.HasPrefix(string(t), string(TypeString)+"-")
+}
+
+// IsMixedContainer returns true if container has different types of children elements.
+// Null elements don't make a container mixed
+func IsMixedContainer(field *FieldInfo) bool {
+	var firstType DetectedType
 	for _, child := range field.Children {
-		if child.Type != firstType {
+		if child.Type == TypeNull {
+			continue
+		}
+		if firstType == "" {
+			firstType = child.Type
+		} else if child.Type != firstType {
 			return true
 		}
 	}
@@ -0,0 +1,246 @@
+package jsontype
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TSOptions configures GenerateTypeScript
+type TSOptions struct {
+	// RootName is the name of the type generated for the root value (default "Root")
+	RootName string
+	// TypeName builds a name of a nested interface, same as GoOptions.TypeName.
+	// Defaults to DefaultGoTypeName
+	TypeName func(root string, path []string) string
+	// BrandedStrings declares branded types (string & { readonly __format: "uuid" })
+	// for extended string types instead of annotating properties with JSDoc @format
+	BrandedStrings bool
+}
+
+var reTSIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+type tsGenerator struct {
+	opts   TSOptions
+	root   []string
+	names  map[string]int
+	brands map[DetectedType]string
+	decls  []string
+}
+
+// GenerateTypeScript emits TypeScript declarations describing the merged tree
+func GenerateTypeScript(m *Merger, opts TSOptions) string {
+	if opts.RootName == "" {
+		opts.RootName = "Root"
+	}
+	if opts.TypeName == nil {
+		opts.TypeName = DefaultGoTypeName
+	}
+
+	g := &tsGenerator{
+		opts:   opts,
+		root:   m.Path,
+		names:  make(map[string]int),
+		brands: make(map[DetectedType]string),
+	}
+
+	rootName := g.reserveName(opts.RootName)
+	if _, isObj := m.TypesMap[TypeObj]; isObj && len(m.TypesMap) == 1 {
+		g.interfaceDecl(m, rootName)
+	} else {
+		idx := len(g.decls)
+		g.decls = append(g.decls, "")
+		g.decls[idx] = fmt.Sprintf("export type %s = %s;\n", rootName, g.tsType(m))
+	}
+
+	var sb strings.Builder
+	sb.WriteString("// Code generated by jsontype. DO NOT EDIT.\n\n")
+	for _, t := range collectTypes(g.brands) {
+		fmt.Fprintf(&sb, "export type %s = string & { readonly __format: %q };\n", g.brands[t], tsFormatName(t))
+	}
+	if len(g.brands) > 0 {
+		sb.WriteByte('\n')
+	}
+	sb.WriteString(strings.Join(g.decls, "\n"))
+	return sb.String()
+}
+
+func (g *tsGenerator) reserveName(name string) string {
+	n := g.names[name]
+	g.names[name]++
+	if n == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s%d", name, n+1)
+}
+
+func (g *tsGenerator) nestedName(m *Merger) string {
+	rel := m.Path
+	if len(rel) >= len(g.root) {
+		rel = rel[len(g.root):]
+	}
+	return g.reserveName(g.opts.TypeName(g.opts.RootName, rel))
+}
+
+// tsType returns a TypeScript type expression for the node,
+// nested objects are declared as interfaces
+func (g *tsGenerator) tsType(m *Merger) string {
+	var members []string
+	var hasNumber, hasBigInt, hasNull bool
+	var strs []DetectedType
+
+	for _, t := range collectTypes(jsonTypes(m.TypesMap)) {
+		switch {
+		case t == TypeUnknown:
+			return "unknown"
+		case t == TypeNull:
+			hasNull = true
+		case t == TypeBool:
+			members = append(members, "boolean")
+		case t == TypeUint64 || t == TypeBigInt:
+			// wider than 53 bits, a number would lose precision
+			hasBigInt = true
+		case IsNumberType(t):
+			hasNumber = true
+		case IsStringType(t):
+			strs = append(strs, t)
+		case t == TypeObj:
+			name := g.nestedName(m)
+			g.interfaceDecl(m, name)
+			members = append(members, name)
+		case t == TypeArray:
+			members = append(members, g.arrayType(m))
+		case t == TypeObjInt:
+			members = append(members, g.objectIntType(m))
+		}
+	}
+
+	if hasNumber {
+		members = append(members, "number")
+	}
+	if hasBigInt {
+		members = append(members, "bigint")
+	}
+	members = append(members, g.stringTypes(strs)...)
+	if hasNull {
+		members = append(members, "null")
+	}
+	if len(members) == 0 {
+		return "unknown"
+	}
+	return strings.Join(members, " | ")
+}
+
+// stringTypes renders strings, keeping extended types as brands if requested
+func (g *tsGenerator) stringTypes(types []DetectedType) []string {
+	if len(types) == 0 {
+		return nil
+	}
+	if !g.opts.BrandedStrings {
+		return []string{"string"}
+	}
+	var out []string
+	plain := false
+	for _, t := range types {
+		if t == TypeString {
+			plain = true
+			continue
+		}
+		brand, ok := g.brands[t]
+		if !ok {
+			brand = GoExportedName(tsFormatName(t)) + "String"
+			g.brands[t] = brand
+		}
+		out = append(out, brand)
+	}
+	// every brand is assignable to string anyway
+	if plain {
+		return []string{"string"}
+	}
+	return out
+}
+
+func (g *tsGenerator) arrayType(m *Merger) string {
+	if elem, collapsed := m.ChildrenMap[""]; collapsed {
+		elemType := g.tsType(elem)
+		if strings.Contains(elemType, " | ") {
+			elemType = "(" + elemType + ")"
+		}
+		return elemType + "[]"
+	}
+	if len(m.ChildrenMap) == 0 {
+		return "unknown[]"
+	}
+	// elements differ per index -- keep them as a tuple
+	items := make([]string, 0, len(m.ChildrenMap))
+	for _, key := range sortedIndexKeys(m) {
+		items = append(items, g.tsType(m.ChildrenMap[key]))
+	}
+	return "[" + strings.Join(items, ", ") + "]"
+}
+
+func (g *tsGenerator) objectIntType(m *Merger) string {
+	if elem, collapsed := m.ChildrenMap[""]; collapsed {
+		return "Record<number, " + g.tsType(elem) + ">"
+	}
+	if len(m.ChildrenMap) == 0 {
+		return "Record<number, unknown>"
+	}
+	fields := make([]string, 0, len(m.ChildrenMap))
+	for _, key := range sortedIndexKeys(m) {
+		fields = append(fields, fmt.Sprintf("%s: %s", key, g.tsType(m.ChildrenMap[key])))
+	}
+	return "{ " + strings.Join(fields, "; ") + " }"
+}
+
+func (g *tsGenerator) interfaceDecl(m *Merger, name string) {
+	// reserve the slot first so parents are declared before children
+	idx := len(g.decls)
+	g.decls = append(g.decls, "")
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "export interface %s {\n", name)
+	for _, key := range m.ChildrenKeys {
+		if key == "" {
+			continue
+		}
+		child := m.ChildrenMap[key]
+
+		if !g.opts.BrandedStrings {
+			if formats := tsFormats(child); formats != "" {
+				fmt.Fprintf(&sb, "  /** @format %s */\n", formats)
+			}
+		}
+
+		prop := key
+		if !reTSIdentifier.MatchString(key) {
+			prop = fmt.Sprintf("%q", key)
+		}
+		optional := ""
+		if child.IsOptional() {
+			optional = "?"
+		}
+		fmt.Fprintf(&sb, "  %s%s: %s;\n", prop, optional, g.tsType(child))
+	}
+	sb.WriteString("}\n")
+	g.decls[idx] = sb.String()
+}
+
+// tsFormats lists formats of extended string types met at the path
+func tsFormats(m *Merger) string {
+	var formats []string
+	for _, t := range collectTypes(jsonTypes(m.TypesMap)) {
+		if IsStringType(t) && t != TypeString {
+			formats = append(formats, tsFormatName(t))
+		}
+	}
+	return strings.Join(formats, " | ")
+}
+
+// tsFormatName prefers JSON Schema format names, falls back to the detected type
+func tsFormatName(t DetectedType) string {
+	if f, ok := stringFormats[t]; ok {
+		return f
+	}
+	return strings.TrimPrefix(string(t), string(TypeString)+"-")
+}
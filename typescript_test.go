@@ -0,0 +1,60 @@
+package jsontype_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/4nd3r5on/jsontype"
+)
+
+func TestGenerateTypeScript(t *testing.T) {
+	merger := mergeJSON(t, `{
+		"id": "f3a9c2e7-6b4d-4f81-9a6c-2d8e5b71c0fa",
+		"tuple": [1, "x"],
+		"scores": {"1": 1.5, "2": 3.5},
+		"huge": 123456789012345678901234567890,
+		"items": [{"a": 1}, {"a": 2, "b": "text"}, {"a": 18446744073709551615, "b": null}]
+	}`)
+
+	tests := []struct {
+		name string
+		opts jsontype.TSOptions
+		want []string
+	}{
+		{
+			name: "jsdoc",
+			opts: jsontype.TSOptions{RootName: "Payload"},
+			want: []string{
+				"export interface Payload {",
+				"/** @format uuid */\n  id: string;",
+				"tuple: [number, string];",
+				"scores: Record<number, number>;",
+				"huge: bigint;",
+				"items: PayloadItemsItem[];",
+				"export interface PayloadItemsItem {",
+				"a: number | bigint;",
+				"b?: string | null;",
+			},
+		},
+		{
+			name: "branded",
+			opts: jsontype.TSOptions{BrandedStrings: true},
+			want: []string{
+				`export type UUIDString = string & { readonly __format: "uuid" };`,
+				"id: UUIDString;",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := jsontype.GenerateTypeScript(merger, tt.opts)
+			t.Log("\n" + src)
+			for _, want := range tt.want {
+				if !strings.Contains(src, want) {
+					t.Errorf("generated code doesn't contain %q", want)
+				}
+			}
+		})
+	}
+}